@@ -6,28 +6,37 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	cfg "github.com/denniswon/tcex/app/config"
+	"github.com/denniswon/tcex/app/dataset"
 	"github.com/gookit/color"
 
 	o "github.com/denniswon/tcex/app/order"
 	"github.com/denniswon/tcex/app/rest"
+	"github.com/denniswon/tcex/app/rpc"
 )
 
 // Run - Application to be invoked from main runner using this function
 func Run(configFile string) {
 
 	ctx, cancel := context.WithCancel(context.Background())
-	requestQueue, replayQueue, _redis := bootstrap(configFile)
+	requestQueue, replayQueue, _broker := bootstrap(configFile)
 
-	// Create a temporary directory
-	tempDir, err := os.MkdirTemp("", cfg.GetUploadDirName())
+	// Uploaded datasets are kept across restarts
+	uploadDir := cfg.GetUploadDir()
 
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		log.Print(color.Red.Sprintf("[!] Failed to create directory for uploads : %s", err.Error()))
+		panic(err)
+	}
+
+	// Datasets uploaded to upload directory, including the ones uploaded before
+	catalog, err := dataset.NewCatalog(uploadDir)
 	if err != nil {
-		log.Print(color.Red.Sprintf("[!] Failed to create temporary directory for uploads : %s", err.Error()))
+		log.Print(color.Red.Sprintf("[!] Failed to create dataset catalog : %s", err.Error()))
 		panic(err)
 	}
-	defer os.RemoveAll(tempDir)
 
 	// Attempting to listen to Ctrl+C signal
 	// and when received gracefully shutting down the service
@@ -46,8 +55,8 @@ func Run(configFile string) {
 		// @note This can ( needs to ) be improved
 		cancel()
 
-		if err := _redis.Close(); err != nil {
-			log.Print(color.Red.Sprintf("[!] Failed to close connection to Redis : %s", err.Error()))
+		if err := _broker.Close(); err != nil {
+			log.Print(color.Red.Sprintf("[!] Failed to close broker : %s", err.Error()))
 			return
 		}
 
@@ -57,8 +66,28 @@ func Run(configFile string) {
 
 	}()
 
-	go o.ProcessOrderReplays(ctx, requestQueue, replayQueue, _redis)
+	go o.ProcessOrderReplays(ctx, requestQueue, replayQueue, _broker)
+
+	// Datasets being replayed are pinned, so that they're not deleted meanwhile
+	requestQueue.SetPinner(catalog)
+
+	// Expired & least recently used datasets are deleted in background,
+	// unless they're being replayed
+	janitor := dataset.NewJanitor(
+		catalog,
+		int64(cfg.GetMaxUploadSize()),
+		time.Duration(cfg.GetPartialUploadTTL())*time.Second,
+		time.Duration(cfg.GetJanitorInterval())*time.Second,
+	)
+	go janitor.Run(ctx)
+
+	// Input files of replays are only ever read from data
+	// directory or from uploaded datasets
+	resolver := dataset.NewResolver(cfg.GetDataDir(), catalog)
+
+	// Starting gRPC server alongside http server, on its own port
+	go rpc.RunGRPCServer(requestQueue, _broker, resolver)
 
 	// Starting http server on main thread
-	rest.RunHTTPServer(requestQueue, _redis, tempDir)
+	rest.RunHTTPServer(requestQueue, _broker, catalog, resolver, uploadDir)
 }
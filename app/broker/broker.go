@@ -0,0 +1,67 @@
+package broker
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrMissing - Returned when looked up key is not present in cache
+var ErrMissing = errors.New("missing key")
+
+// ErrTimeout - Returned when nothing is received over subscription within given timeout
+var ErrTimeout = errors.New("receive timeout")
+
+// Broker - Pub/Sub messaging & key/value cache, used for passing replayed
+// orders from publishers to consumers
+type Broker interface {
+	Publish(ctx context.Context, channel string, message interface{}) error
+	Subscribe(ctx context.Context, channel string) Subscription
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}) error
+	Del(ctx context.Context, keys ...string) error
+	DelPrefix(ctx context.Context, prefix string) error
+	Close() error
+}
+
+// Subscription - Handle to channel subscribed to, over which
+// published messages are received
+type Subscription interface {
+	Receive(ctx context.Context, timeout time.Duration) (*Message, error)
+	Unsubscribe(ctx context.Context) error
+}
+
+// Message - Either message published over subscribed channel, or confirmation
+// of subscription state change i.e. `subscribe`/ `unsubscribe`
+type Message struct {
+	Kind    string // `subscribe`/ `unsubscribe`/ `message`
+	Channel string
+	Payload string
+}
+
+// encode - Converts published message/ cached value into its wire
+// form, same as Redis client does
+func encode(value interface{}) (string, error) {
+
+	switch v := value.(type) {
+
+	case string:
+		return v, nil
+
+	case []byte:
+		return string(v), nil
+
+	case encoding.BinaryMarshaler:
+		data, err := v.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+
+		return string(data), nil
+
+	}
+
+	return "", fmt.Errorf("can't encode value of type %T", value)
+}
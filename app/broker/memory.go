@@ -0,0 +1,219 @@
+package broker
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory - In-process broker, to be used when running single
+// instance of the server without Redis
+type Memory struct {
+	cache         map[string]string
+	subscriptions map[string]map[*MemorySubscription]bool
+	mutex         *sync.RWMutex
+}
+
+// NewMemory - Empty in-process broker
+func NewMemory() *Memory {
+	return &Memory{
+		cache:         make(map[string]string),
+		subscriptions: make(map[string]map[*MemorySubscription]bool),
+		mutex:         &sync.RWMutex{},
+	}
+}
+
+// Publish - Delivers message to all current subscribers of channel,
+// it's dropped if there's none, same as Redis does
+func (m *Memory) Publish(ctx context.Context, channel string, message interface{}) error {
+
+	payload, err := encode(message)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for sub := range m.subscriptions[channel] {
+		sub.push(&Message{Kind: "message", Channel: channel, Payload: payload})
+	}
+
+	return nil
+}
+
+// Subscribe - Subscribes to channel, confirmation of which is
+// received as first message over subscription
+func (m *Memory) Subscribe(ctx context.Context, channel string) Subscription {
+
+	sub := &MemorySubscription{
+		Channel: channel,
+		broker:  m,
+		signal:  make(chan struct{}, 1),
+		mutex:   &sync.Mutex{},
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.subscriptions[channel] == nil {
+		m.subscriptions[channel] = make(map[*MemorySubscription]bool)
+	}
+
+	m.subscriptions[channel][sub] = true
+	sub.push(&Message{Kind: "subscribe", Channel: channel})
+
+	return sub
+}
+
+// Get - Looks up cached value
+func (m *Memory) Get(ctx context.Context, key string) (string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	value, ok := m.cache[key]
+	if !ok {
+		return "", ErrMissing
+	}
+
+	return value, nil
+}
+
+// Set - Caches value
+func (m *Memory) Set(ctx context.Context, key string, value interface{}) error {
+
+	_value, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.cache[key] = _value
+	return nil
+}
+
+// Del - Removes cached values
+func (m *Memory) Del(ctx context.Context, keys ...string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, key := range keys {
+		delete(m.cache, key)
+	}
+
+	return nil
+}
+
+// DelPrefix - Removes all cached values, keys of which start with given prefix
+func (m *Memory) DelPrefix(ctx context.Context, prefix string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for key := range m.cache {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.cache, key)
+		}
+	}
+
+	return nil
+}
+
+// Close - Forgets all cached values & subscriptions
+func (m *Memory) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.cache = make(map[string]string)
+	m.subscriptions = make(map[string]map[*MemorySubscription]bool)
+
+	return nil
+}
+
+// MemorySubscription - Subscription to in-process broker channel, where messages
+// are queued without any bound, so that publisher never blocks on slow consumer
+type MemorySubscription struct {
+	Channel string
+	broker  *Memory
+	queue   []*Message
+	signal  chan struct{}
+	mutex   *sync.Mutex
+}
+
+// push - Queues message for subscriber & wakes it up, if waiting
+func (s *MemorySubscription) push(msg *Message) {
+	s.mutex.Lock()
+	s.queue = append(s.queue, msg)
+	s.mutex.Unlock()
+
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+// pop - Next queued message, if any
+func (s *MemorySubscription) pop() (*Message, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.queue) == 0 {
+		return nil, false
+	}
+
+	msg := s.queue[0]
+	s.queue[0] = nil
+	s.queue = s.queue[1:]
+
+	return msg, true
+}
+
+// Receive - Waits for next message over subscribed channel, at max for given timeout
+func (s *MemorySubscription) Receive(ctx context.Context, timeout time.Duration) (*Message, error) {
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+
+		if msg, ok := s.pop(); ok {
+			return msg, nil
+		}
+
+		select {
+
+		case <-s.signal:
+
+		case <-timer.C:
+			return nil, ErrTimeout
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
+		}
+
+	}
+}
+
+// Unsubscribe - Stops receiving messages published over channel,
+// confirmation of which is received as last message
+func (s *MemorySubscription) Unsubscribe(ctx context.Context) error {
+
+	s.broker.mutex.Lock()
+	defer s.broker.mutex.Unlock()
+
+	subs, ok := s.broker.subscriptions[s.Channel]
+	if !ok || !subs[s] {
+		return nil
+	}
+
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(s.broker.subscriptions, s.Channel)
+	}
+
+	s.push(&Message{Kind: "unsubscribe", Channel: s.Channel})
+	return nil
+}
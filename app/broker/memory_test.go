@@ -0,0 +1,258 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestMemoryDelPrefix(t *testing.T) {
+
+	keys := []string{"a:1", "a:2", "ab:1", "a*b:1", "a*b:2", "a?:1", "b:1"}
+
+	tests := []struct {
+		name   string
+		prefix string
+		kept   []string
+	}{
+		{"plain", "a:", []string{"a*b:1", "a*b:2", "a?:1", "ab:1", "b:1"}},
+		{"star is literal", "a*b:", []string{"a:1", "a:2", "a?:1", "ab:1", "b:1"}},
+		{"question mark is literal", "a?", []string{"a*b:1", "a*b:2", "a:1", "a:2", "ab:1", "b:1"}},
+		{"no match", "c", []string{"a*b:1", "a*b:2", "a:1", "a:2", "a?:1", "ab:1", "b:1"}},
+		{"empty prefix", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx := context.Background()
+			m := NewMemory()
+
+			for _, key := range keys {
+				if err := m.Set(ctx, key, "v"); err != nil {
+					t.Fatalf("Set(%q) : %s", key, err)
+				}
+			}
+
+			if err := m.DelPrefix(ctx, tt.prefix); err != nil {
+				t.Fatalf("DelPrefix(%q) : %s", tt.prefix, err)
+			}
+
+			kept := []string{}
+			for _, key := range keys {
+				if _, err := m.Get(ctx, key); err == nil {
+					kept = append(kept, key)
+				}
+			}
+			sort.Strings(kept)
+
+			if len(kept) != len(tt.kept) {
+				t.Fatalf("DelPrefix(%q) kept %v, expected %v", tt.prefix, kept, tt.kept)
+			}
+
+			for i := range kept {
+				if kept[i] != tt.kept[i] {
+					t.Fatalf("DelPrefix(%q) kept %v, expected %v", tt.prefix, kept, tt.kept)
+				}
+			}
+
+		})
+	}
+
+}
+
+func TestMemoryCache(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    string
+		invalid bool
+	}{
+		{"string", "price", "price", false},
+		{"bytes", []byte("quantity"), "quantity", false},
+		{"unsupported", 42, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx := context.Background()
+			m := NewMemory()
+
+			if _, err := m.Get(ctx, "key"); !errors.Is(err, ErrMissing) {
+				t.Fatalf("Get before Set : expected ErrMissing, got %v", err)
+			}
+
+			err := m.Set(ctx, "key", tt.value)
+			if tt.invalid {
+				if err == nil {
+					t.Fatalf("Set : expected error for %T", tt.value)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Set : %s", err)
+			}
+
+			value, err := m.Get(ctx, "key")
+			if err != nil {
+				t.Fatalf("Get : %s", err)
+			}
+
+			if value != tt.want {
+				t.Fatalf("Get : expected %q, got %q", tt.want, value)
+			}
+
+			if err := m.Del(ctx, "key", "unknown"); err != nil {
+				t.Fatalf("Del : %s", err)
+			}
+
+			if _, err := m.Get(ctx, "key"); !errors.Is(err, ErrMissing) {
+				t.Fatalf("Get after Del : expected ErrMissing, got %v", err)
+			}
+
+		})
+	}
+
+}
+
+func TestMemoryPubSub(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		subscribe []string // channels subscribed to, in order
+		publish   []string // channels published over, in order
+		expected  [][]string
+	}{
+		{
+			name:      "no subscriber",
+			subscribe: []string{},
+			publish:   []string{"a"},
+			expected:  [][]string{},
+		},
+		{
+			name:      "one subscriber",
+			subscribe: []string{"a"},
+			publish:   []string{"a", "b", "a"},
+			expected:  [][]string{{"subscribe:a", "message:a", "message:a"}},
+		},
+		{
+			name:      "fan out",
+			subscribe: []string{"a", "a", "b"},
+			publish:   []string{"a", "b"},
+			expected: [][]string{
+				{"subscribe:a", "message:a"},
+				{"subscribe:a", "message:a"},
+				{"subscribe:b", "message:b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx := context.Background()
+			m := NewMemory()
+
+			subs := make([]Subscription, 0, len(tt.subscribe))
+			for _, channel := range tt.subscribe {
+				subs = append(subs, m.Subscribe(ctx, channel))
+			}
+
+			for _, channel := range tt.publish {
+				if err := m.Publish(ctx, channel, channel); err != nil {
+					t.Fatalf("Publish : %s", err)
+				}
+			}
+
+			for i, sub := range subs {
+
+				for _, expected := range tt.expected[i] {
+
+					msg, err := sub.Receive(ctx, time.Second)
+					if err != nil {
+						t.Fatalf("subscription %d : expected %s, got %s", i, expected, err)
+					}
+
+					if got := msg.Kind + ":" + msg.Channel; got != expected {
+						t.Fatalf("subscription %d : expected %s, got %s", i, expected, got)
+					}
+
+					if msg.Kind == "message" && msg.Payload != msg.Channel {
+						t.Fatalf("subscription %d : expected payload %q, got %q", i, msg.Channel, msg.Payload)
+					}
+
+				}
+
+				if msg, err := sub.Receive(ctx, 10*time.Millisecond); !errors.Is(err, ErrTimeout) {
+					t.Fatalf("subscription %d : expected ErrTimeout, got %v %v", i, msg, err)
+				}
+
+			}
+
+		})
+	}
+
+}
+
+func TestMemoryUnsubscribe(t *testing.T) {
+
+	ctx := context.Background()
+	m := NewMemory()
+
+	sub := m.Subscribe(ctx, "a")
+	other := m.Subscribe(ctx, "a")
+
+	for _, s := range []Subscription{sub, other} {
+		if msg, err := s.Receive(ctx, time.Second); err != nil || msg.Kind != "subscribe" {
+			t.Fatalf("expected subscribe confirmation, got %v %v", msg, err)
+		}
+	}
+
+	// Unsubscribing twice is confirmed only once
+	for i := 0; i < 2; i++ {
+		if err := sub.Unsubscribe(ctx); err != nil {
+			t.Fatalf("Unsubscribe : %s", err)
+		}
+	}
+
+	if err := m.Publish(ctx, "a", "after"); err != nil {
+		t.Fatalf("Publish : %s", err)
+	}
+
+	if msg, err := sub.Receive(ctx, time.Second); err != nil || msg.Kind != "unsubscribe" {
+		t.Fatalf("expected unsubscribe confirmation, got %v %v", msg, err)
+	}
+
+	if msg, err := sub.Receive(ctx, 10*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected nothing after unsubscribing, got %v %v", msg, err)
+	}
+
+	// Other subscriber of the same channel keeps receiving
+	if msg, err := other.Receive(ctx, time.Second); err != nil || msg.Payload != "after" {
+		t.Fatalf("expected message over remaining subscription, got %v %v", msg, err)
+	}
+
+}
+
+func TestMemoryReceiveCancelled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := NewMemory()
+
+	sub := m.Subscribe(ctx, "a")
+	if _, err := sub.Receive(ctx, time.Second); err != nil {
+		t.Fatalf("Receive : %s", err)
+	}
+
+	cancel()
+
+	if _, err := sub.Receive(ctx, time.Second); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+}
@@ -0,0 +1,137 @@
+package broker
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis - Broker backed by Redis server, to be used when
+// running multiple instances or with external consumers
+type Redis struct {
+	Client *redis.Client
+}
+
+// NewRedis - Broker using given Redis client
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{Client: client}
+}
+
+// Publish - Publishes message over Redis pubsub channel
+func (r *Redis) Publish(ctx context.Context, channel string, message interface{}) error {
+	return r.Client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe - Subscribes to Redis pubsub channel, waiting till server confirms it, so that
+// nothing published right after is missed. Confirmation is still received as first message
+func (r *Redis) Subscribe(ctx context.Context, channel string) Subscription {
+
+	sub := &RedisSubscription{
+		PubSub:  r.Client.Subscribe(ctx, channel),
+		Channel: channel,
+	}
+
+	msg, err := sub.PubSub.Receive(ctx)
+	if err != nil {
+		log.Printf("[!] Failed to confirm subscription to %s : %s\n", channel, err.Error())
+		return sub
+	}
+
+	if m, ok := msg.(*redis.Subscription); ok {
+		sub.confirmed = &Message{Kind: m.Kind, Channel: m.Channel}
+	}
+
+	return sub
+}
+
+// Get - Looks up cached value
+func (r *Redis) Get(ctx context.Context, key string) (string, error) {
+	value, err := r.Client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", ErrMissing
+	}
+
+	return value, err
+}
+
+// Set - Caches value, without expiry
+func (r *Redis) Set(ctx context.Context, key string, value interface{}) error {
+	return r.Client.Set(ctx, key, value, 0).Err()
+}
+
+// Del - Removes cached values
+func (r *Redis) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return r.Client.Del(ctx, keys...).Err()
+}
+
+// DelPrefix - Removes all cached values, keys of which start with given prefix, which
+// is matched literally, same as in-process broker does, never as glob pattern
+func (r *Redis) DelPrefix(ctx context.Context, prefix string) error {
+
+	iter := r.Client.Scan(ctx, 0, globEscaper.Replace(prefix)+"*", 1000).Iterator()
+
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	return r.Del(ctx, keys...)
+}
+
+// globEscaper - Escapes characters having special meaning in Redis glob patterns
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// Close - Closes connection to Redis server
+func (r *Redis) Close() error {
+	return r.Client.Close()
+}
+
+// RedisSubscription - Subscription to Redis pubsub channel
+type RedisSubscription struct {
+	PubSub    *redis.PubSub
+	Channel   string
+	confirmed *Message // confirmation of subscription, yet to be received
+}
+
+// Receive - Waits for next message over subscribed channel, at max for given timeout
+func (s *RedisSubscription) Receive(ctx context.Context, timeout time.Duration) (*Message, error) {
+
+	if msg := s.confirmed; msg != nil {
+		s.confirmed = nil
+		return msg, nil
+	}
+
+	msg, err := s.PubSub.ReceiveTimeout(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	switch m := msg.(type) {
+
+	case *redis.Subscription:
+		return &Message{Kind: m.Kind, Channel: m.Channel}, nil
+
+	case *redis.Message:
+		return &Message{Kind: "message", Channel: m.Channel, Payload: m.Payload}, nil
+
+	}
+
+	// Anything else i.e. pong is not of interest
+	return nil, ErrTimeout
+}
+
+// Unsubscribe - Unsubscribes from Redis pubsub channel
+func (s *RedisSubscription) Unsubscribe(ctx context.Context) error {
+	return s.PubSub.Unsubscribe(ctx, s.Channel)
+}
@@ -2,12 +2,41 @@ package app
 
 import (
 	"context"
+	"log"
 
 	"github.com/go-redis/redis/v8"
 
+	"github.com/denniswon/tcex/app/broker"
 	cfg "github.com/denniswon/tcex/app/config"
 )
 
+// Creates pub/sub & cache broker as configured in `.env` file, returns nil
+// if it's unknown or Redis server can't be connected to
+func getBroker() broker.Broker {
+
+	switch cfg.GetBroker() {
+
+	case "memory":
+		return broker.NewMemory()
+
+	case "redis":
+		_redis := getRedisClient()
+		if _redis == nil {
+			log.Printf("[!] Failed to connect to Redis Server\n")
+			return nil
+		}
+
+		if err := _redis.FlushAll(context.Background()).Err(); err != nil {
+			log.Printf("[!] Failed to flush all keys from redis : %s\n", err.Error())
+		}
+
+		return broker.NewRedis(_redis)
+
+	}
+
+	return nil
+}
+
 // Creates connection to Redis server & returns that handle to be used for further communication
 func getRedisClient() *redis.Client {
 
@@ -37,14 +37,113 @@ func GetConcurrencyFactor() uint64 {
 	return parsedFactor
 }
 
-// GetUploadDirName - name of the temp directory for uploading files
-func GetUploadDirName() string {
+// GetUploadDir - Directory uploaded datasets are stored in, which is kept across
+// restarts, specified in `.env` file. `uploads` in working directory by default
+func GetUploadDir() string {
 
-	name := Get("UploadDirName")
-	if name == "" {
+	dir := Get("UploadDir")
+	if dir == "" {
 		return "uploads"
 	}
 
+	return dir
+}
+
+// GetDatasetTTL - For how long ( in seconds ) uploaded dataset is kept since it was last
+// uploaded or replayed, unless given along with upload, specified in `.env` file. 0 means
+// datasets never expire
+func GetDatasetTTL() uint64 {
+
+	seconds := Get("DatasetTTL")
+	if seconds == "" {
+		return 0
+	}
+
+	parsedSeconds, err := strconv.ParseUint(seconds, 10, 64)
+	if err != nil {
+		log.Printf("[!] Failed to parse dataset ttl : %s\n", err.Error())
+		return 0
+	}
+
+	return parsedSeconds
+}
+
+// GetMaxUploadSize - Maximum total size ( in bytes ) of uploaded datasets, beyond which
+// least recently used ones are deleted, specified in `.env` file. 0 means no limit
+func GetMaxUploadSize() uint64 {
+
+	size := Get("MaxUploadSize")
+	if size == "" {
+		return 0
+	}
+
+	parsedSize, err := strconv.ParseUint(size, 10, 64)
+	if err != nil {
+		log.Printf("[!] Failed to parse max upload size : %s\n", err.Error())
+		return 0
+	}
+
+	return parsedSize
+}
+
+// GetPartialUploadTTL - For how long ( in seconds ) partially received chunked upload is
+// kept since it was last resumed, specified in `.env` file. 0 means it's never deleted
+func GetPartialUploadTTL() uint64 {
+
+	seconds := Get("PartialUploadTTL")
+	if seconds == "" {
+		return 86400
+	}
+
+	parsedSeconds, err := strconv.ParseUint(seconds, 10, 64)
+	if err != nil {
+		log.Printf("[!] Failed to parse partial upload ttl : %s\n", err.Error())
+		return 86400
+	}
+
+	return parsedSeconds
+}
+
+// GetJanitorInterval - How often ( in seconds ) expired & least recently used
+// datasets are looked for, specified in `.env` file
+func GetJanitorInterval() uint64 {
+
+	seconds := Get("JanitorInterval")
+	if seconds == "" {
+		return 60
+	}
+
+	parsedSeconds, err := strconv.ParseUint(seconds, 10, 64)
+	if err != nil || parsedSeconds == 0 {
+		log.Printf("[!] Failed to parse janitor interval : %s\n", seconds)
+		return 60
+	}
+
+	return parsedSeconds
+}
+
+// GetDataDir - Directory input files of replays are looked up in, besides uploaded
+// datasets, specified in `.env` file. `data` in working directory is used by default, never
+// working directory itself, as it holds `.env` & upload directory
+func GetDataDir() string {
+
+	dir := Get("DataDir")
+	if dir == "" {
+		return "data"
+	}
+
+	return dir
+}
+
+// GetBroker - Returns pub/sub & cache broker to be used i.e. `redis` or `memory`,
+// specified in `.env` file. Redis is used by default
+func GetBroker() string {
+
+	name := Get("Broker")
+	if name == "" {
+		return "redis"
+	}
+
 	return name
 }
 
@@ -52,3 +151,45 @@ func GetUploadDirName() string {
 func GetPort() string {
 	return Get("PORT")
 }
+
+// GetGRPCPort - Returns port number of gRPC server specified in `.env` file, where gRPC
+// server is not started if none is specified
+func GetGRPCPort() string {
+	return Get("GRPCPort")
+}
+
+// GetLookAheadOrders - Maximum number of orders of a replay, which can be read ahead of
+// time & waiting to be published, specified in `.env` file. 0 means no limit
+func GetLookAheadOrders() uint64 {
+
+	orders := Get("LookAheadOrders")
+	if orders == "" {
+		return 1000
+	}
+
+	parsedOrders, err := strconv.ParseUint(orders, 10, 64)
+	if err != nil {
+		log.Printf("[!] Failed to parse look ahead orders : %s\n", err.Error())
+		return 1000
+	}
+
+	return parsedOrders
+}
+
+// GetLookAheadTime - How far ahead of current replay position ( in seconds of original
+// trade time ) orders can be read, specified in `.env` file. 0 means no limit
+func GetLookAheadTime() uint64 {
+
+	seconds := Get("LookAheadTime")
+	if seconds == "" {
+		return 60
+	}
+
+	parsedSeconds, err := strconv.ParseUint(seconds, 10, 64)
+	if err != nil {
+		log.Printf("[!] Failed to parse look ahead time : %s\n", err.Error())
+		return 60
+	}
+
+	return parsedSeconds
+}
@@ -14,8 +14,16 @@ type Kline struct {
 	Open								float64 `json:"open"`  				// opening price (first trade) in the bucket interval
 	Close 							float64 `json:"close"`  			// closing price (last trade) in the bucket interval
 	Volume 							int64 	`json:"volume"`  			// net quantity volume of trading activity during the bucket interval
+	BuyVolume           uint64  `json:"buy_volume"`   // quantity bought by aggressors during the bucket interval
+	SellVolume          uint64  `json:"sell_volume"`  // quantity sold by aggressors during the bucket interval
+	GrossVolume         uint64  `json:"gross_volume"` // total quantity traded during the bucket interval
+	Trades              uint64  `json:"trades"`       // number of trades during the bucket interval
 	Turnover						float64 `json:"turnover"`			// total usd volume of trading activity during the bucket interval
-	Granularity         uint16  `json:"granularity"`	// granularity field is in "seconds"
+	VWAP                float64 `json:"vwap"`         // volume weighted average price during the bucket interval, 0 if nothing traded
+	Granularity         uint16  `json:"granularity"`	// granularity field is in "seconds", 0 for bars other than time buckets
+	BarType             string  `json:"bar_type"`     // "time", "tick", "volume" or "dollar"
+	Threshold           float64 `json:"threshold"`    // # of trades, quantity or turnover closing the bar, 0 for time buckets
+	Closed              bool    `json:"closed"`       // whether bucket interval is over, so that it won't change anymore
 }
 
 // MarshalBinary - Implementing binary marshalling function, to be invoked
@@ -26,15 +34,23 @@ func (k *Kline) MarshalBinary() ([]byte, error) {
 
 // MarshalJSON - Custom JSON encoder
 func (k *Kline) MarshalJSON() ([]byte, error) {
-	return []byte(fmt.Sprintf(`{"timestamp":%d,"low":%f,"high":%f,"open":%f,"close":%f,"volume":%d,"turnover":%f,"granularity":%d}`,
+	return []byte(fmt.Sprintf(`{"timestamp":%d,"low":%f,"high":%f,"open":%f,"close":%f,"volume":%d,"buy_volume":%d,"sell_volume":%d,"gross_volume":%d,"trades":%d,"turnover":%f,"vwap":%f,"granularity":%d,"bar_type":%q,"threshold":%f,"closed":%t}`,
 		k.Timestamp,
 		k.Low,
 		k.High,
 		k.Open,
 		k.Close,
 		k.Volume,
+		k.BuyVolume,
+		k.SellVolume,
+		k.GrossVolume,
+		k.Trades,
 		k.Turnover,
+		k.VWAP,
 		k.Granularity,
+		k.BarType,
+		k.Threshold,
+		k.Closed,
 	)), nil
 }
 
@@ -0,0 +1,39 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// Loop - Replay loop marker to be delivered to client in this format, in place
+// of replay EOF, whenever replay starts over
+type Loop struct {
+	RequestID string `json:"request_id"`
+	Loop      uint64 `json:"loop"` // number of loops replayed so far
+}
+
+// MarshalBinary - Implementing binary marshalling function, to be invoked
+// by redis before publishing data on channel
+func (l *Loop) MarshalBinary() ([]byte, error) {
+	return json.Marshal(l)
+}
+
+// MarshalJSON - Custom JSON encoder
+func (l *Loop) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"request_id":%q,"loop":%d}`,
+		l.RequestID,
+		l.Loop,
+	)), nil
+}
+
+// ToJSON - Encodes into JSON, to be supplied when queried for loop marker data
+func (l *Loop) ToJSON() []byte {
+	data, err := json.Marshal(l)
+	if err != nil {
+		log.Printf("[!] Failed to encode loop marker data to JSON : %s\n", err.Error())
+		return nil
+	}
+
+	return data
+}
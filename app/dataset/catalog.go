@@ -0,0 +1,344 @@
+package dataset
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/denniswon/tcex/app/source"
+)
+
+// ErrMissing - No dataset with given id
+var ErrMissing = errors.New("dataset not found")
+
+// Catalog - Datasets uploaded so far, where metadata of each of them is persisted
+// as JSON file in its own directory, next to uploaded files
+//
+// Uploaded files are stored by sha256 of their content, which is id of the dataset,
+// so that same content uploaded under different names is stored only once, while
+// different content uploaded under same name is never mixed up
+type Catalog struct {
+	root     string
+	dir      string
+	datasets map[string]*Dataset
+	pins     map[string]int // # of replays of each dataset, which is not deleted by janitor meanwhile
+	mutex    sync.RWMutex
+}
+
+// NewCatalog - Creates catalog of datasets uploaded to given directory, loading
+// metadata of the ones uploaded before, possibly before restart
+func NewCatalog(uploadDir string) (*Catalog, error) {
+
+	dir := filepath.Join(uploadDir, ".datasets")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	// Files being validated when server went down are never going to be added,
+	// while partially received chunked uploads are kept, for being resumed
+	for _, pattern := range []string{".upload-*", ".validated-*"} {
+
+		leftovers, err := filepath.Glob(filepath.Join(uploadDir, pattern))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, leftover := range leftovers {
+			os.Remove(leftover)
+		}
+
+	}
+
+	catalog := &Catalog{
+		root:     uploadDir,
+		dir:      dir,
+		datasets: make(map[string]*Dataset),
+		pins:     make(map[string]int),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var dataset Dataset
+		if err := json.Unmarshal(data, &dataset); err != nil {
+			log.Printf("[!] Failed to decode dataset metadata %s : %s\n", entry.Name(), err.Error())
+			continue
+		}
+
+		catalog.datasets[dataset.ID] = &dataset
+
+	}
+
+	return catalog, nil
+}
+
+// Add - Describes uploaded file, decoded as per given options & moves it into storage, as
+// dataset identified by sha256 of its content, persisting its metadata, which expires once
+// it's not used for given ttl ( in seconds ). If same content has already been uploaded, that
+// dataset is returned, with given name mapped to it too & its ttl renewed
+func (c *Catalog) Add(filename string, upload string, options source.Options, ttl int64) (*Dataset, bool, error) {
+
+	now := time.Now().UnixMilli()
+
+	dataset := &Dataset{
+		Filename:      filename,
+		Names:         []string{filename},
+		Filepath:      upload,
+		UploadedAt:    now,
+		FileFormat:    options.Format,
+		TimestampUnit: options.Unit,
+		Columns:       options.Columns,
+		TTL:           ttl,
+		LastUsedAt:    now,
+	}
+
+	if err := describe(dataset); err != nil {
+		return nil, false, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if existing, ok := c.datasets[dataset.SHA256]; ok {
+
+		if err := os.Remove(upload); err != nil {
+			return nil, false, err
+		}
+
+		// Metadata is replaced as a whole, as it might be being read
+		named := *existing
+		named.TTL = ttl
+		named.LastUsedAt = now
+
+		if !existing.Named(filename) {
+			named.Names = append([]string{}, existing.Names...)
+			if len(named.Names) == 0 {
+				named.Names = append(named.Names, existing.Filename)
+			}
+			named.Names = append(named.Names, filename)
+		}
+
+		if err := c.persist(&named); err != nil {
+			return nil, false, err
+		}
+
+		return &named, true, nil
+
+	}
+
+	dataset.ID = dataset.SHA256
+	dataset.Filepath = filepath.Join(c.root, dataset.ID)
+
+	if err := os.Rename(upload, dataset.Filepath); err != nil {
+		return nil, false, err
+	}
+
+	if err := c.persist(dataset); err != nil {
+		return nil, false, err
+	}
+
+	return dataset, false, nil
+}
+
+// Get - Looks up dataset by its id
+func (c *Catalog) Get(id string) (*Dataset, bool) {
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	dataset, ok := c.datasets[id]
+	return dataset, ok
+}
+
+// Touch - Marks dataset as used now, for being replayed, so that it's
+// neither expired nor evicted before the ones used earlier
+func (c *Catalog) Touch(id string) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	existing, ok := c.datasets[id]
+	if !ok {
+		return
+	}
+
+	used := *existing
+	used.LastUsedAt = time.Now().UnixMilli()
+
+	if err := c.persist(&used); err != nil {
+		log.Printf("[!] Failed to persist usage of dataset %s : %s\n", id, err.Error())
+	}
+
+}
+
+// Find - Looks up dataset stored in given file
+func (c *Catalog) Find(_filepath string) (*Dataset, bool) {
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	for _, dataset := range c.datasets {
+		if dataset.Filepath == _filepath {
+			return dataset, true
+		}
+	}
+
+	return nil, false
+}
+
+// List - All datasets, or only the ones uploaded under given name if
+// it's not empty, in order of their upload
+func (c *Catalog) List(name string) []*Dataset {
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	datasets := make([]*Dataset, 0, len(c.datasets))
+	for _, dataset := range c.datasets {
+		if name == "" || dataset.Named(name) {
+			datasets = append(datasets, dataset)
+		}
+	}
+
+	sort.Slice(datasets, func(i, j int) bool {
+		if datasets[i].UploadedAt != datasets[j].UploadedAt {
+			return datasets[i].UploadedAt < datasets[j].UploadedAt
+		}
+
+		return datasets[i].ID < datasets[j].ID
+	})
+
+	return datasets
+}
+
+// Pin - Marks file as being replayed, so that it's not deleted by janitor till it's unpinned,
+// returning false if it's file of dataset, which has already been deleted. Files other than
+// the ones of datasets are never deleted, so those are not tracked
+func (c *Catalog) Pin(path string) bool {
+
+	if filepath.Dir(path) != filepath.Clean(c.root) {
+		return true
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	id := filepath.Base(path)
+	if _, ok := c.datasets[id]; !ok {
+		return false
+	}
+
+	c.pins[id]++
+
+	return true
+}
+
+// Unpin - Marks file as not being replayed anymore, for one replay it was pinned for
+func (c *Catalog) Unpin(path string) {
+
+	if filepath.Dir(path) != filepath.Clean(c.root) {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	id := filepath.Base(path)
+	if c.pins[id] <= 1 {
+		delete(c.pins, id)
+		return
+	}
+
+	c.pins[id]--
+
+}
+
+// Remove - Deletes dataset along with its file, where replays already
+// reading it keep going till their end
+func (c *Catalog) Remove(id string) (*Dataset, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.remove(id)
+}
+
+// RemoveUnpinned - Deletes dataset along with its file, unless it's being replayed,
+// returning whether it got deleted
+func (c *Catalog) RemoveUnpinned(id string) (bool, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.pins[id] > 0 {
+		return false, nil
+	}
+
+	if _, err := c.remove(id); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// remove - Deletes dataset along with its file, to be invoked while holding lock
+func (c *Catalog) remove(id string) (*Dataset, error) {
+
+	dataset, ok := c.datasets[id]
+	if !ok {
+		return nil, ErrMissing
+	}
+
+	if err := os.Remove(dataset.Filepath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if err := os.Remove(c.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	delete(c.datasets, id)
+
+	return dataset, nil
+}
+
+// persist - Writes metadata of dataset & keeps it, to be looked up
+func (c *Catalog) persist(dataset *Dataset) error {
+
+	data, err := json.Marshal(dataset)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(c.path(dataset.ID), data, 0644); err != nil {
+		return err
+	}
+
+	c.datasets[dataset.ID] = dataset
+
+	return nil
+}
+
+// path - Where metadata of dataset with given id is persisted
+func (c *Catalog) path(id string) string {
+	return filepath.Join(c.dir, id+".json")
+}
@@ -0,0 +1,332 @@
+package dataset
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/denniswon/tcex/app/source"
+)
+
+const (
+	trades  = "{\"price\":\"1.5\",\"quantity\":10,\"aggressor\":\"buy\",\"timestamp\":1000}\n{\"price\":\"2.5\",\"quantity\":5,\"aggressor\":\"sell\",\"timestamp\":2000}\n"
+	others  = "{\"price\":\"3.5\",\"quantity\":1,\"aggressor\":\"buy\",\"timestamp\":3000}\n"
+	tabular = "price,quantity,aggressor,timestamp\n1.5,10,buy,1000\n"
+)
+
+// upload - Writes uploaded content into upload directory of catalog & adds it
+func upload(t *testing.T, catalog *Catalog, name string, content string) (*Dataset, bool) {
+	t.Helper()
+
+	path := filepath.Join(catalog.root, ".upload-"+name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dataset, duplicate, err := catalog.Add(name, path, source.Options{}, 60)
+	if err != nil {
+		t.Fatalf("Add(%s) : %s", name, err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Add(%s) : expected upload to be moved or removed, got %v", name, err)
+	}
+
+	return dataset, duplicate
+}
+
+func TestCatalogDedup(t *testing.T) {
+
+	type added struct {
+		name      string
+		content   string
+		duplicate bool
+		names     []string
+	}
+
+	tests := []struct {
+		name     string
+		uploads  []added
+		datasets int
+	}{
+		{
+			name: "same content under different names",
+			uploads: []added{
+				{"a.jsonl", trades, false, []string{"a.jsonl"}},
+				{"b.jsonl", trades, true, []string{"a.jsonl", "b.jsonl"}},
+			},
+			datasets: 1,
+		},
+		{
+			name: "same content under same name",
+			uploads: []added{
+				{"a.jsonl", trades, false, []string{"a.jsonl"}},
+				{"a.jsonl", trades, true, []string{"a.jsonl"}},
+			},
+			datasets: 1,
+		},
+		{
+			name: "different content under same name",
+			uploads: []added{
+				{"a.jsonl", trades, false, []string{"a.jsonl"}},
+				{"a.jsonl", others, false, []string{"a.jsonl"}},
+			},
+			datasets: 2,
+		},
+		{
+			name: "different formats",
+			uploads: []added{
+				{"a.jsonl", trades, false, []string{"a.jsonl"}},
+				{"a.csv", tabular, false, []string{"a.csv"}},
+				{"b.csv", tabular, true, []string{"a.csv", "b.csv"}},
+			},
+			datasets: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			catalog, err := NewCatalog(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, add := range tt.uploads {
+
+				dataset, duplicate := upload(t, catalog, add.name, add.content)
+
+				if duplicate != add.duplicate {
+					t.Fatalf("upload %d : expected duplicate %t, got %t", i, add.duplicate, duplicate)
+				}
+
+				if !reflect.DeepEqual(dataset.Names, add.names) {
+					t.Fatalf("upload %d : expected names %v, got %v", i, add.names, dataset.Names)
+				}
+
+				if dataset.ID != dataset.SHA256 || dataset.Filepath != filepath.Join(catalog.root, dataset.ID) {
+					t.Fatalf("upload %d : expected file stored by its sha256, got %s at %s", i, dataset.ID, dataset.Filepath)
+				}
+
+				stored, err := os.ReadFile(dataset.Filepath)
+				if err != nil || string(stored) != add.content {
+					t.Fatalf("upload %d : expected stored file as uploaded, got %q %v", i, stored, err)
+				}
+
+			}
+
+			if got := len(catalog.List("")); got != tt.datasets {
+				t.Fatalf("expected %d datasets, got %d", tt.datasets, got)
+			}
+
+			// Metadata survives restart
+			reloaded, err := NewCatalog(catalog.root)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, dataset := range catalog.List("") {
+				_dataset, ok := reloaded.Get(dataset.ID)
+				if !ok || !reflect.DeepEqual(_dataset, dataset) {
+					t.Fatalf("reloaded %s : expected %+v, got %+v", dataset.ID, dataset, _dataset)
+				}
+			}
+
+		})
+	}
+
+}
+
+func TestCatalogDescribe(t *testing.T) {
+
+	catalog, err := NewCatalog(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataset, _ := upload(t, catalog, "a.jsonl", trades+"\n"+"garbage\n")
+
+	expected := Dataset{
+		Rows:           2,
+		FirstTimestamp: 1000,
+		LastTimestamp:  2000,
+		MinPrice:       1.5,
+		MaxPrice:       2.5,
+		FileFormat:     source.JSONL,
+		TimestampUnit:  "ms",
+	}
+
+	got := Dataset{
+		Rows:           dataset.Rows,
+		FirstTimestamp: dataset.FirstTimestamp,
+		LastTimestamp:  dataset.LastTimestamp,
+		MinPrice:       dataset.MinPrice,
+		MaxPrice:       dataset.MaxPrice,
+		FileFormat:     dataset.FileFormat,
+		TimestampUnit:  dataset.TimestampUnit,
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+
+}
+
+func TestCatalogRemove(t *testing.T) {
+
+	catalog, err := NewCatalog(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataset, _ := upload(t, catalog, "a.jsonl", trades)
+	other, _ := upload(t, catalog, "b.jsonl", others)
+
+	if got := catalog.List("b.jsonl"); len(got) != 1 || got[0].ID != other.ID {
+		t.Fatalf("List(b.jsonl) : expected %s only, got %v", other.ID, got)
+	}
+
+	if _, err := catalog.Remove(dataset.ID); err != nil {
+		t.Fatalf("Remove : %s", err)
+	}
+
+	if _, err := catalog.Remove(dataset.ID); !errors.Is(err, ErrMissing) {
+		t.Fatalf("Remove twice : expected ErrMissing, got %v", err)
+	}
+
+	if _, ok := catalog.Get(dataset.ID); ok {
+		t.Fatal("Get after Remove : expected dataset to be gone")
+	}
+
+	if _, err := os.Stat(dataset.Filepath); !os.IsNotExist(err) {
+		t.Fatalf("stored file after Remove : expected it to be deleted, got %v", err)
+	}
+
+	// Removed dataset stays deleted after restart, while other one is kept
+	reloaded, err := NewCatalog(catalog.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := reloaded.List(""); len(got) != 1 || got[0].ID != other.ID {
+		t.Fatalf("reloaded : expected %s only, got %v", other.ID, got)
+	}
+
+}
+
+func TestCatalogPin(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		pins    int
+		unpins  int
+		removed bool
+	}{
+		{"not pinned", 0, 0, true},
+		{"pinned", 1, 0, false},
+		{"pinned twice, unpinned once", 2, 1, false},
+		{"unpinned", 2, 2, true},
+		{"unpinned more than pinned", 1, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			catalog, err := NewCatalog(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dataset, _ := upload(t, catalog, "a.jsonl", trades)
+
+			for i := 0; i < tt.pins; i++ {
+				if !catalog.Pin(dataset.Filepath) {
+					t.Fatal("Pin : expected existing dataset to be pinned")
+				}
+			}
+
+			for i := 0; i < tt.unpins; i++ {
+				catalog.Unpin(dataset.Filepath)
+			}
+
+			removed, err := catalog.RemoveUnpinned(dataset.ID)
+			if err != nil {
+				t.Fatalf("RemoveUnpinned : %s", err)
+			}
+
+			if removed != tt.removed {
+				t.Fatalf("RemoveUnpinned : expected %t, got %t", tt.removed, removed)
+			}
+
+			if _, ok := catalog.Get(dataset.ID); ok == removed {
+				t.Fatalf("Get after RemoveUnpinned : expected present %t", !removed)
+			}
+
+			if _, err := os.Stat(dataset.Filepath); os.IsNotExist(err) != removed {
+				t.Fatalf("stored file after RemoveUnpinned : expected removed %t, got %v", removed, err)
+			}
+
+			// Deleted dataset can't be pinned anymore
+			if removed && catalog.Pin(dataset.Filepath) {
+				t.Fatal("Pin : expected deleted dataset not to be pinned")
+			}
+
+		})
+	}
+
+}
+
+func TestCatalogPinOtherFiles(t *testing.T) {
+
+	catalog, err := NewCatalog(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Files outside upload directory are never deleted, so they're not tracked
+	for _, path := range []string{"data/trades.txt", filepath.Join(catalog.root, "sub", "trades.txt")} {
+
+		if !catalog.Pin(path) {
+			t.Fatalf("Pin(%s) : expected true", path)
+		}
+
+		catalog.Unpin(path)
+
+	}
+
+	if len(catalog.pins) != 0 {
+		t.Fatalf("expected no pins, got %v", catalog.pins)
+	}
+
+}
+
+func TestNewCatalogLeftovers(t *testing.T) {
+
+	dir := t.TempDir()
+
+	files := map[string]bool{
+		".upload-1":    false,
+		".validated-1": false,
+		".partial-1":   true,
+		"trades.txt":   true,
+	}
+
+	for name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := NewCatalog(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, kept := range files {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != kept {
+			t.Fatalf("%s : expected kept %t, got %v", name, kept, err)
+		}
+	}
+
+}
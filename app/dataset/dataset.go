@@ -0,0 +1,179 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/denniswon/tcex/app/source"
+)
+
+// Dataset - Metadata of uploaded input file, which can be replayed
+type Dataset struct {
+	ID             string  `json:"id"`       // sha256 of stored file
+	Filename       string  `json:"filename"` // original name of uploaded file
+	Filepath       string  `json:"filepath"` // where it's stored on server, while subscription requests refer to it by `id`
+	Size           int64   `json:"size"`
+	SHA256         string  `json:"sha256"`
+	Rows           uint64  `json:"rows"`            // # of trades in file
+	FirstTimestamp int64   `json:"first_timestamp"` // unix timestamp of first trade in milliseconds
+	LastTimestamp  int64   `json:"last_timestamp"`  // unix timestamp of last trade in milliseconds
+	MinPrice       float64 `json:"min_price"`
+	MaxPrice       float64 `json:"max_price"`
+	UploadedAt     int64   `json:"uploaded_at"` // unix timestamp in milliseconds
+
+	FileFormat    string            `json:"file_format"`           // "jsonl", "csv" or "tsv"
+	TimestampUnit string            `json:"timestamp_unit"`        // unit of trade timestamps in file, which are still described in milliseconds
+	Columns       map[string]string `json:"columns,omitempty"`     // header name of each trade field, in csv & tsv files
+	Compression   string            `json:"compression,omitempty"` // "gzip" or "zstd", if file is stored compressed
+
+	Names []string `json:"names"` // all original names same content has been uploaded under
+
+	TTL        int64 `json:"ttl"`          // seconds dataset is kept since it was last used, 0 if it never expires
+	LastUsedAt int64 `json:"last_used_at"` // unix timestamp in milliseconds, of last upload or replay
+}
+
+// LastUsed - When dataset was last uploaded or replayed, in milliseconds
+func (d *Dataset) LastUsed() int64 {
+
+	// Datasets added before usage was tracked
+	if d.LastUsedAt == 0 {
+		return d.UploadedAt
+	}
+
+	return d.LastUsedAt
+}
+
+// Expired - Whether dataset hasn't been used for longer than its ttl, as of given time
+func (d *Dataset) Expired(now time.Time) bool {
+	return d.TTL > 0 && now.UnixMilli()-d.LastUsed() > d.TTL*1000
+}
+
+// Named - Whether dataset has been uploaded under given name
+func (d *Dataset) Named(name string) bool {
+
+	// Datasets added before names were tracked
+	if len(d.Names) == 0 {
+		return d.Filename == name
+	}
+
+	for _, _name := range d.Names {
+		if _name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Source - How file of dataset is to be decoded, when replaying it
+func (d *Dataset) Source() source.Options {
+	return source.Options{
+		Format:  d.FileFormat,
+		Unit:    d.TimestampUnit,
+		Columns: d.Columns,
+	}
+}
+
+// describe - Reads whole file once, for filling in its size, checksum & trade
+// statistics, where lines not holding a trade are skipped. Compressed file is
+// decompressed as it's read, while its size & checksum are the stored ones
+func describe(dataset *Dataset) error {
+
+	file, err := source.Open(dataset.Filepath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder, err := source.NewDecoder(file, dataset.Filename, dataset.Source())
+	if err != nil {
+		return err
+	}
+
+	dataset.FileFormat = decoder.Format
+	dataset.TimestampUnit = decoder.Unit
+	dataset.Columns = decoder.Columns
+	dataset.Compression = file.Compression
+
+	hash := sha256.New()
+	stored := io.TeeReader(file, hash)
+
+	reader, err := source.NewReader(stored, file.Compression)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	dataset.Size = 0
+	dataset.Rows = 0
+
+	// Header line, if any, is only hashed
+	header := decoder.Start > 0
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+
+		if header {
+			header = false
+			continue
+		}
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		trade, err := decoder.Decode(line)
+		if err != nil {
+			continue
+		}
+
+		price, err := strconv.ParseFloat(trade.Price, 64)
+		if err != nil {
+			continue
+		}
+
+		if dataset.Rows == 0 {
+			dataset.FirstTimestamp = trade.Timestamp
+			dataset.MinPrice = price
+			dataset.MaxPrice = price
+		}
+
+		dataset.LastTimestamp = trade.Timestamp
+
+		if price < dataset.MinPrice {
+			dataset.MinPrice = price
+		}
+
+		if price > dataset.MaxPrice {
+			dataset.MaxPrice = price
+		}
+
+		dataset.Rows++
+
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// Whatever is stored past compressed stream is still hashed
+	if _, err := io.Copy(io.Discard, stored); err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	dataset.Size = info.Size()
+	dataset.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	return nil
+}
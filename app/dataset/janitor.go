@@ -0,0 +1,143 @@
+package dataset
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Janitor - Deletes datasets, which haven't been used for longer than their ttl, and
+// least recently used ones, while total size of stored files exceeds the limit. Datasets
+// being replayed i.e. pinned in catalog are never deleted, they're looked at again on next sweep
+type Janitor struct {
+	catalog    *Catalog
+	maxSize    int64         // in bytes, 0 if there's no limit
+	partialTTL time.Duration // of partially received uploads, 0 if they're kept forever
+	interval   time.Duration // between sweeps
+}
+
+// NewJanitor - Creates janitor of given catalog, where partially received
+// uploads, which haven't been resumed for given ttl, are deleted too
+func NewJanitor(catalog *Catalog, maxSize int64, partialTTL time.Duration, interval time.Duration) *Janitor {
+	return &Janitor{
+		catalog:    catalog,
+		maxSize:    maxSize,
+		partialTTL: partialTTL,
+		interval:   interval,
+	}
+}
+
+// Run - Sweeps catalog periodically, until context is cancelled
+func (j *Janitor) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+
+		j.Sweep(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+	}
+
+}
+
+// Sweep - Deletes expired datasets first & then least recently used ones,
+// as long as total size of datasets is over the limit
+func (j *Janitor) Sweep(now time.Time) {
+
+	datasets := j.catalog.List("")
+
+	// Least recently used ones go first
+	sort.SliceStable(datasets, func(i, k int) bool {
+		return datasets[i].LastUsed() < datasets[k].LastUsed()
+	})
+
+	kept := make([]*Dataset, 0, len(datasets))
+	size := int64(0)
+
+	for _, dataset := range datasets {
+
+		if dataset.Expired(now) && j.remove(dataset, "expired") {
+			continue
+		}
+
+		kept = append(kept, dataset)
+		size += dataset.Size
+
+	}
+
+	for _, dataset := range kept {
+
+		if j.maxSize <= 0 || size <= j.maxSize {
+			break
+		}
+
+		if j.remove(dataset, "evicted") {
+			size -= dataset.Size
+		}
+
+	}
+
+	j.sweepPartials(now)
+
+}
+
+// remove - Deletes dataset, unless it's being replayed, returning whether it got deleted
+func (j *Janitor) remove(dataset *Dataset, reason string) bool {
+
+	// Being replayed is checked while deleting, so that no replay
+	// can start reading it in the meantime
+	removed, err := j.catalog.RemoveUnpinned(dataset.ID)
+	if err != nil {
+		log.Printf("[!] Failed to remove %s dataset %s : %s\n", reason, dataset.ID, err.Error())
+		return false
+	}
+
+	if !removed {
+		return false
+	}
+
+	log.Printf("Removed %s dataset %s (%s, size: %d)\n", reason, dataset.ID, dataset.Filename, dataset.Size)
+
+	return true
+
+}
+
+// sweepPartials - Deletes partially received uploads, which haven't been resumed for ttl
+func (j *Janitor) sweepPartials(now time.Time) {
+
+	if j.partialTTL <= 0 {
+		return
+	}
+
+	partials, err := filepath.Glob(filepath.Join(j.catalog.root, ".partial-*"))
+	if err != nil {
+		return
+	}
+
+	for _, partial := range partials {
+
+		info, err := os.Stat(partial)
+		if err != nil || now.Sub(info.ModTime()) <= j.partialTTL {
+			continue
+		}
+
+		if err := os.Remove(partial); err != nil {
+			log.Printf("[!] Failed to remove stale upload %s : %s\n", partial, err.Error())
+			continue
+		}
+
+		log.Printf("Removed stale upload %s\n", partial)
+
+	}
+
+}
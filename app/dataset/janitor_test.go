@@ -0,0 +1,181 @@
+package dataset
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// use - Marks dataset as used at given time, expiring after given ttl ( in seconds )
+func use(t *testing.T, catalog *Catalog, id string, at time.Time, ttl int64) {
+	t.Helper()
+
+	catalog.mutex.Lock()
+	defer catalog.mutex.Unlock()
+
+	used := *catalog.datasets[id]
+	used.LastUsedAt = at.UnixMilli()
+	used.TTL = ttl
+
+	if err := catalog.persist(&used); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJanitorSweep(t *testing.T) {
+
+	now := time.UnixMilli(1_700_000_000_000)
+
+	type stored struct {
+		name    string
+		content string
+		used    time.Duration // how long ago dataset was last used
+		ttl     int64
+		pinned  bool
+	}
+
+	// `b` & `c` are of same size, `a` being smaller, so that size limit tells how many of them are kept
+	a := stored{name: "a.jsonl", content: trades, used: 3 * time.Second}
+	b := stored{name: "b.jsonl", content: trades + others, used: 2 * time.Second}
+	c := stored{name: "c.jsonl", content: others + trades, used: time.Second}
+	size := int64(len(trades + others))
+
+	expired := func(s stored) stored { s.used, s.ttl = 2*time.Minute, 60; return s }
+	fresh := func(s stored) stored { s.ttl = 60; return s }
+	pinned := func(s stored) stored { s.pinned = true; return s }
+
+	tests := []struct {
+		name     string
+		datasets []stored
+		maxSize  int64
+		kept     []string
+	}{
+		{"nothing to delete", []stored{a, b, c}, 0, []string{"a.jsonl", "b.jsonl", "c.jsonl"}},
+		{"expired", []stored{expired(a), fresh(b), c}, 0, []string{"b.jsonl", "c.jsonl"}},
+		{"least recently used evicted", []stored{c, a, b}, 2 * size, []string{"b.jsonl", "c.jsonl"}},
+		{"evicted down to size", []stored{a, b, c}, size, []string{"c.jsonl"}},
+		{"expired before evicting", []stored{a, b, expired(c)}, 2 * size, []string{"a.jsonl", "b.jsonl"}},
+		{"pinned not evicted", []stored{pinned(a), b, c}, size, []string{"a.jsonl"}},
+		{"pinned not expired", []stored{pinned(expired(a)), b, c}, 2 * size, []string{"a.jsonl", "c.jsonl"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			catalog, err := NewCatalog(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, s := range tt.datasets {
+
+				dataset, _ := upload(t, catalog, s.name, s.content)
+				use(t, catalog, dataset.ID, now.Add(-s.used), s.ttl)
+
+				if s.pinned && !catalog.Pin(dataset.Filepath) {
+					t.Fatalf("Pin(%s) : expected true", s.name)
+				}
+
+			}
+
+			NewJanitor(catalog, tt.maxSize, 0, time.Minute).Sweep(now)
+
+			kept := []string{}
+			for _, dataset := range catalog.List("") {
+
+				kept = append(kept, dataset.Filename)
+
+				if _, err := os.Stat(dataset.Filepath); err != nil {
+					t.Fatalf("kept %s : expected its file to be kept, got %s", dataset.Filename, err)
+				}
+
+			}
+			sort.Strings(kept)
+
+			if !reflect.DeepEqual(kept, tt.kept) {
+				t.Fatalf("expected %v to be kept, got %v", tt.kept, kept)
+			}
+
+			entries, err := os.ReadDir(catalog.root)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			files := []string{}
+			for _, entry := range entries {
+				if !strings.HasPrefix(entry.Name(), ".") {
+					files = append(files, entry.Name())
+				}
+			}
+
+			if len(files) != len(tt.kept) {
+				t.Fatalf("expected only files of kept datasets, got %v", files)
+			}
+
+		})
+	}
+
+}
+
+func TestJanitorSweepPartials(t *testing.T) {
+
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		partialTTL time.Duration
+		kept       []string
+	}{
+		{"stale upload removed", time.Hour, []string{".partial-fresh"}},
+		{"kept forever", 0, []string{".partial-fresh", ".partial-stale"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			catalog, err := NewCatalog(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			received := map[string]time.Time{
+				".partial-stale": now.Add(-2 * time.Hour),
+				".partial-fresh": now.Add(-time.Minute),
+			}
+
+			for name, at := range received {
+				path := filepath.Join(catalog.root, name)
+				if err := os.WriteFile(path, []byte(trades), 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.Chtimes(path, at, at); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			NewJanitor(catalog, 0, tt.partialTTL, time.Minute).Sweep(now)
+
+			partials, err := filepath.Glob(filepath.Join(catalog.root, ".partial-*"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			kept := []string{}
+			for _, partial := range partials {
+				kept = append(kept, filepath.Base(partial))
+			}
+			sort.Strings(kept)
+
+			if !reflect.DeepEqual(kept, tt.kept) {
+				t.Fatalf("expected %v to be kept, got %v", tt.kept, kept)
+			}
+
+		})
+	}
+
+}
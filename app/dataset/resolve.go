@@ -0,0 +1,126 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutside - Input file name pointing outside of managed directories
+var ErrOutside = errors.New("input file outside of data directories")
+
+// Resolver - Finds input files to be replayed, only ever within data directory
+// or among uploaded datasets, so that no other file on server can be read
+type Resolver struct {
+	dataDir string
+	catalog *Catalog
+}
+
+// NewResolver - Creates resolver of input file names, which are looked up in given data
+// directory first & then among uploaded datasets, by their stored file name
+func NewResolver(dataDir string, catalog *Catalog) *Resolver {
+	return &Resolver{dataDir: dataDir, catalog: catalog}
+}
+
+// Dataset - Looks up uploaded dataset by its id, which is marked as used
+// for being replayed
+func (r *Resolver) Dataset(id string) (*Dataset, error) {
+
+	dataset, ok := r.catalog.Get(id)
+	if !ok {
+		return nil, ErrMissing
+	}
+
+	r.catalog.Touch(id)
+
+	return dataset, nil
+}
+
+// Resolve - Finds input file with given name, relative to data directory, or stored file
+// of uploaded dataset. Absolute paths & ones climbing up with `..` are rejected, as are
+// symbolic links leading outside, and hidden files i.e. `.env`, or ones within hidden
+// directories, where in-progress uploads & metadata of datasets are kept
+//
+// Only stored files of datasets are looked up in upload directory, by their id, never
+// anything else within it, even if it's within data directory
+func (r *Resolver) Resolve(filename string) (string, error) {
+
+	if !filepath.IsLocal(filename) || hidden(filename) {
+		return "", ErrOutside
+	}
+
+	if r.dataDir != "" {
+
+		path := filepath.Join(r.dataDir, filename)
+
+		info, err := os.Stat(path)
+		if err == nil && info.Mode().IsRegular() {
+
+			if ok, err := within(r.dataDir, path); err != nil || !ok {
+				return "", ErrOutside
+			}
+
+			// Upload directory might be configured within data directory
+			if ok, err := within(r.catalog.root, path); err != nil || ok {
+				return "", ErrOutside
+			}
+
+			return path, nil
+
+		}
+
+	}
+
+	if dataset, err := r.Dataset(filename); err == nil {
+		return dataset.Filepath, nil
+	}
+
+	return "", fmt.Errorf("input file not found : %s", filename)
+}
+
+// hidden - Whether any component of given relative path starts with `.`
+func hidden(filename string) bool {
+
+	for _, part := range strings.Split(filepath.ToSlash(filename), "/") {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// within - Whether file is within given directory, once symbolic links are followed,
+// where either of them might be relative to working directory, while other one is not
+func within(dir string, path string) (bool, error) {
+
+	_dir, err := absolute(dir)
+	if err != nil {
+		return false, err
+	}
+
+	_path, err := absolute(path)
+	if err != nil {
+		return false, err
+	}
+
+	rel, err := filepath.Rel(_dir, _path)
+	if err != nil {
+		return false, err
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
+// absolute - Absolute path of file, once symbolic links are followed
+func absolute(path string) (string, error) {
+
+	_path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Abs(_path)
+}
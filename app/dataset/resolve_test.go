@@ -0,0 +1,207 @@
+package dataset
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "data")
+	uploadDir := filepath.Join(dataDir, "uploads")
+
+	for _, dir := range []string{filepath.Join(dataDir, "sub"), filepath.Join(dataDir, ".hidden"), uploadDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"trades.txt", "sub/trades.txt", ".env", ".hidden/trades.txt", "../secret.txt"} {
+		if err := os.WriteFile(filepath.Join(dataDir, name), []byte(trades), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		"alias.txt":    "trades.txt",
+		"escape.txt":   "../secret.txt",
+		"absolute.txt": filepath.Join(root, "secret.txt"),
+		"sub/up.txt":   "../../data/trades.txt",
+		"passwd":       "/etc/passwd",
+	}
+
+	for link, target := range links {
+		if err := os.Symlink(target, filepath.Join(dataDir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	catalog, err := NewCatalog(uploadDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataset, _ := upload(t, catalog, "uploaded.jsonl", trades)
+
+	if err := os.Symlink(filepath.Join("uploads", dataset.ID), filepath.Join(dataDir, "stored.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := NewResolver(dataDir, catalog)
+
+	tests := []struct {
+		name     string
+		filename string
+		expected string
+		outside  bool
+	}{
+		{"file in data directory", "trades.txt", filepath.Join(dataDir, "trades.txt"), false},
+		{"file in subdirectory", "sub/trades.txt", filepath.Join(dataDir, "sub/trades.txt"), false},
+		{"symlink within data directory", "alias.txt", filepath.Join(dataDir, "alias.txt"), false},
+		{"relative symlink back into data directory", "sub/up.txt", filepath.Join(dataDir, "sub/up.txt"), false},
+		{"dataset by id", dataset.ID, dataset.Filepath, false},
+		{"parent directory", "../secret.txt", "", true},
+		{"climbing through subdirectory", "sub/../../secret.txt", "", true},
+		{"climbing within data directory", "sub/../trades.txt", "", true},
+		{"absolute path", filepath.Join(root, "secret.txt"), "", true},
+		{"system file", "/etc/passwd", "", true},
+		{"hidden file", ".env", "", true},
+		{"file in hidden directory", ".hidden/trades.txt", "", true},
+		{"dataset metadata", "uploads/.datasets/" + dataset.ID + ".json", "", true},
+		{"relative symlink escaping", "escape.txt", "", true},
+		{"absolute symlink escaping", "absolute.txt", "", true},
+		{"symlink to system file", "passwd", "", true},
+		{"stored file by path", "uploads/" + dataset.ID, "", true},
+		{"symlink to stored file", "stored.txt", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			path, err := resolver.Resolve(tt.filename)
+
+			if tt.outside {
+				if !errors.Is(err, ErrOutside) {
+					t.Fatalf("Resolve(%s) : expected ErrOutside, got %q %v", tt.filename, path, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Resolve(%s) : unexpected error : %s", tt.filename, err)
+			}
+
+			if path != tt.expected {
+				t.Fatalf("Resolve(%s) : expected %s, got %s", tt.filename, tt.expected, path)
+			}
+
+		})
+	}
+
+}
+
+func TestResolveMissing(t *testing.T) {
+
+	dataDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dataDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, err := NewCatalog(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		dataDir  string
+		filename string
+	}{
+		{"unknown file", dataDir, "unknown.txt"},
+		{"directory", dataDir, "sub"},
+		{"unknown dataset", dataDir, strings.Repeat("0", 64)},
+		{"no data directory", "", "trades.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			path, err := NewResolver(tt.dataDir, catalog).Resolve(tt.filename)
+			if err == nil || errors.Is(err, ErrOutside) {
+				t.Fatalf("Resolve(%s) : expected not found, got %q %v", tt.filename, path, err)
+			}
+
+		})
+	}
+
+}
+
+func TestHidden(t *testing.T) {
+
+	tests := []struct {
+		filename string
+		hidden   bool
+	}{
+		{"trades.txt", false},
+		{"sub/trades.txt", false},
+		{"trades.v1.txt", false},
+		{".env", true},
+		{".hidden/trades.txt", true},
+		{"sub/.trades.txt", true},
+		{"sub/.git/config", true},
+	}
+
+	for _, tt := range tests {
+		if got := hidden(tt.filename); got != tt.hidden {
+			t.Errorf("hidden(%s) : expected %t, got %t", tt.filename, tt.hidden, got)
+		}
+	}
+
+}
+
+func TestWithin(t *testing.T) {
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outside := filepath.Join(t.TempDir(), "trades.txt")
+	if err := os.WriteFile(outside, []byte(trades), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Directories configured in `.env` might be relative to working directory or not
+	tests := []struct {
+		name   string
+		dir    string
+		path   string
+		within bool
+	}{
+		{"both relative", ".", "resolve.go", true},
+		{"relative directory", ".", filepath.Join(cwd, "resolve.go"), true},
+		{"relative file", cwd, "resolve.go", true},
+		{"absolute file outside relative directory", ".", outside, false},
+		{"relative file outside absolute directory", filepath.Dir(outside), "resolve.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ok, err := within(tt.dir, tt.path)
+			if err != nil {
+				t.Fatalf("within(%s, %s) : %s", tt.dir, tt.path, err)
+			}
+
+			if ok != tt.within {
+				t.Fatalf("within(%s, %s) : expected %t, got %t", tt.dir, tt.path, tt.within, ok)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,187 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/denniswon/tcex/app/source"
+)
+
+// MaxIssues - Max number of invalid lines, which are reported one by one
+const MaxIssues = 100
+
+// Issue - Line of uploaded file, which can't be replayed
+type Issue struct {
+	Line  uint64 `json:"line"` // starting from 1
+	Error string `json:"error"`
+}
+
+// Report - Outcome of validating uploaded file
+type Report struct {
+	Accepted bool    `json:"accepted"`
+	Strict   bool    `json:"strict"`  // whether file is rejected, if any of its lines is invalid
+	Lines    uint64  `json:"lines"`   // # of lines read
+	Invalid  uint64  `json:"invalid"` // # of invalid lines, which are dropped if file is still accepted
+	Issues   []Issue `json:"issues"`  // first `MaxIssues` invalid lines
+}
+
+// Validate - Checks each line of uploaded file holds a trade, which can be replayed, copying
+// valid lines over to destination file, so that invalid ones are dropped from it, which is
+// only to be stored in place of uploaded file, if there's any invalid line. Header
+// line of csv & tsv files is copied as is, given it has all trade fields, and compressed
+// file is decompressed for validation, while destination file is compressed the same way
+//
+// Blank lines are neither checked nor copied over, given they're skipped when replaying
+//
+// Trades need to have positive price, `bid` or `ask` aggressor and timestamps not
+// decreasing from line to line. File is accepted if all lines are valid, or when
+// not being strict about it, with invalid lines reported as warnings
+func Validate(src string, dst string, name string, options source.Options, strict bool) (*Report, error) {
+
+	in, err := source.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	report := &Report{Strict: strict, Issues: []Issue{}}
+
+	// Format of uploaded file is detected from its original name
+	decoder, err := source.NewDecoder(in, name, options)
+	if err != nil {
+
+		report.Lines = 1
+		report.Invalid = 1
+		report.Issues = append(report.Issues, Issue{Line: 1, Error: err.Error()})
+
+		return report, nil
+
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+
+	compressor, err := source.NewWriter(out, in.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := bufio.NewWriter(compressor)
+
+	reader, err := in.Section(0)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var last int64
+	var valid uint64
+
+	// Header line, if any, is copied without being checked
+	header := decoder.Start > 0
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+
+		report.Lines++
+
+		line := scanner.Bytes()
+
+		if header {
+
+			header = false
+
+			if _, err := writer.Write(line); err != nil {
+				return nil, err
+			}
+
+			if err := writer.WriteByte('\n'); err != nil {
+				return nil, err
+			}
+
+			continue
+
+		}
+
+		// Blank lines are skipped, same as when file is described or replayed
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		timestamp, err := check(decoder, line, last, valid > 0)
+		if err != nil {
+
+			report.Invalid++
+			if len(report.Issues) < MaxIssues {
+				report.Issues = append(report.Issues, Issue{Line: report.Lines, Error: err.Error()})
+			}
+
+			continue
+
+		}
+
+		last = timestamp
+		valid++
+
+		if _, err := writer.Write(line); err != nil {
+			return nil, err
+		}
+
+		if err := writer.WriteByte('\n'); err != nil {
+			return nil, err
+		}
+
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	if err := compressor.Close(); err != nil {
+		return nil, err
+	}
+
+	report.Accepted = valid > 0 && (report.Invalid == 0 || !strict)
+
+	return report, nil
+}
+
+// check - Decodes line as trade & checks whether it can be replayed after
+// the previous valid one, returning its timestamp
+func check(decoder *source.Decoder, line []byte, last int64, after bool) (int64, error) {
+
+	order, err := decoder.Decode(line)
+	if err != nil {
+		return 0, fmt.Errorf("not a trade : %s", err.Error())
+	}
+
+	price, err := strconv.ParseFloat(order.Price, 64)
+	if err != nil || math.IsNaN(price) || math.IsInf(price, 0) {
+		return 0, fmt.Errorf("bad price `%s`", order.Price)
+	}
+
+	if price <= 0 {
+		return 0, fmt.Errorf("non-positive price `%s`", order.Price)
+	}
+
+	if order.Aggressor != "bid" && order.Aggressor != "ask" {
+		return 0, fmt.Errorf("unknown aggressor `%s`", order.Aggressor)
+	}
+
+	if after && order.Timestamp < last {
+		return 0, fmt.Errorf("timestamp %d before previous %d", order.Timestamp, last)
+	}
+
+	return order.Timestamp, nil
+}
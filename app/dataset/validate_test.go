@@ -0,0 +1,161 @@
+package dataset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/denniswon/tcex/app/source"
+)
+
+// validate - Validates given uploaded content, returning report along with file of valid lines
+func validate(t *testing.T, name string, content string, strict bool) (*Report, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "upload")
+	dst := filepath.Join(dir, "validated")
+
+	if err := os.WriteFile(src, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Validate(src, dst, name, source.Options{}, strict)
+	if err != nil {
+		t.Fatalf("Validate : %s", err)
+	}
+
+	validated, _ := os.ReadFile(dst)
+	return report, string(validated)
+}
+
+// trade - JSON encoded trade line
+func trade(price string, aggressor string, timestamp int64) string {
+	return fmt.Sprintf(`{"price":"%s","quantity":1,"aggressor":"%s","timestamp":%d}`, price, aggressor, timestamp)
+}
+
+func TestValidate(t *testing.T) {
+
+	lines := []string{
+		trade("1.5", "bid", 1000),
+		"garbage",
+		trade("0", "bid", 2000),
+		"",
+		trade("NaN", "ask", 2000),
+		trade("2", "buy", 2000),
+		trade("2", "ask", 500),
+		trade("2.5", "ask", 2000),
+	}
+	content := strings.Join(lines, "\n") + "\n"
+
+	// Line numbers count blank lines too, as they're lines of uploaded file
+	issues := []string{
+		"2 not a trade",
+		"3 non-positive price `0`",
+		"5 bad price `NaN`",
+		"6 unknown aggressor `buy`",
+		"7 timestamp 500 before previous 1000",
+	}
+
+	valid := lines[0] + "\n" + lines[7] + "\n"
+
+	tests := []struct {
+		name     string
+		content  string
+		strict   bool
+		accepted bool
+		lines    uint64
+		invalid  uint64
+		issues   []string
+		valid    string
+	}{
+		{"strict", content, true, false, 8, 5, issues, valid},
+		{"not strict", content, false, true, 8, 5, issues, valid},
+		{"all valid", valid, true, true, 2, 0, []string{}, valid},
+		{"no valid line", "garbage\n", false, false, 1, 1, []string{"1 not a trade"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			report, validated := validate(t, "trades.jsonl", tt.content, tt.strict)
+
+			if report.Accepted != tt.accepted || report.Strict != tt.strict || report.Lines != tt.lines || report.Invalid != tt.invalid {
+				t.Fatalf("expected accepted %t, strict %t, %d lines, %d invalid, got %+v",
+					tt.accepted, tt.strict, tt.lines, tt.invalid, report)
+			}
+
+			got := make([]string, 0, len(report.Issues))
+			for _, issue := range report.Issues {
+				got = append(got, fmt.Sprintf("%d %s", issue.Line, issue.Error))
+			}
+
+			if len(got) != len(tt.issues) {
+				t.Fatalf("expected issues %q, got %q", tt.issues, got)
+			}
+
+			for i := range got {
+				if !strings.HasPrefix(got[i], tt.issues[i]) {
+					t.Fatalf("expected issues %q, got %q", tt.issues, got)
+				}
+			}
+
+			if validated != tt.valid {
+				t.Fatalf("expected valid lines %q, got %q", tt.valid, validated)
+			}
+
+		})
+	}
+
+}
+
+func TestValidateHeader(t *testing.T) {
+
+	content := "price,quantity,aggressor,timestamp\n1.5,1,bid,1000\n1.5,1,x,2000\n"
+
+	report, validated := validate(t, "trades.csv", content, false)
+
+	// Header line is copied as is, while still being counted
+	if !report.Accepted || report.Lines != 3 || len(report.Issues) != 1 || report.Issues[0].Line != 3 {
+		t.Fatalf("expected invalid line 3 only, got %+v", report)
+	}
+
+	if validated != "price,quantity,aggressor,timestamp\n1.5,1,bid,1000\n" {
+		t.Fatalf("expected header & valid line, got %q", validated)
+	}
+
+}
+
+func TestValidateIssuesCap(t *testing.T) {
+
+	var content strings.Builder
+	content.WriteString(trade("1", "bid", 1000) + "\n")
+	for i := 0; i < MaxIssues+5; i++ {
+		content.WriteString("garbage\n")
+	}
+
+	for _, strict := range []bool{true, false} {
+		t.Run(fmt.Sprintf("strict %t", strict), func(t *testing.T) {
+
+			report, _ := validate(t, "trades.jsonl", content.String(), strict)
+
+			// All invalid lines are counted, while only first ones are reported
+			if report.Invalid != MaxIssues+5 || len(report.Issues) != MaxIssues {
+				t.Fatalf("expected %d invalid lines & %d issues, got %d & %d",
+					MaxIssues+5, MaxIssues, report.Invalid, len(report.Issues))
+			}
+
+			if first, last := report.Issues[0].Line, report.Issues[MaxIssues-1].Line; first != 2 || last != MaxIssues+1 {
+				t.Fatalf("expected issues of lines 2 to %d, got %d to %d", MaxIssues+1, first, last)
+			}
+
+			if report.Accepted == strict {
+				t.Fatalf("expected accepted %t, got %t", !strict, report.Accepted)
+			}
+
+		})
+	}
+
+}
@@ -1,13 +1,13 @@
 package order
 
 import (
+	"github.com/denniswon/tcex/app/broker"
 	d "github.com/denniswon/tcex/app/data"
 	q "github.com/denniswon/tcex/app/queue"
-	"github.com/go-redis/redis/v8"
 )
 
-// PublishReplayOrder - Attempts to process order data from Redis pubsub channel
-func PublishReplayOrder(orderId string, order *d.Order, queue *q.ReplayQueue, redis *redis.Client) bool {
+// PublishReplayOrder - Attempts to process order data from pubsub channel
+func PublishReplayOrder(orderId string, order *d.Order, queue *q.ReplayQueue, _broker broker.Broker) bool {
 
 	// -- 3 step pub/sub attempt
 
@@ -17,7 +17,7 @@ func PublishReplayOrder(orderId string, order *d.Order, queue *q.ReplayQueue, re
 	}
 
 	// 2. Attempting to publish order on Pub/Sub topic
-	if !PublishOrder(orderId, order, redis) {
+	if !PublishOrder(orderId, order, _broker) {
 		return false
 	}
 
@@ -29,8 +29,8 @@ func PublishReplayOrder(orderId string, order *d.Order, queue *q.ReplayQueue, re
 	return true
 }
 
-// PublishReplayKline - Attempts to process kline data from Redis pubsub channel
-func PublishReplayKline(orderId string, kline *d.Kline, queue *q.ReplayQueue, redis *redis.Client) bool {
+// PublishReplayKline - Attempts to process kline data from pubsub channel
+func PublishReplayKline(orderId string, kline *d.Kline, queue *q.ReplayQueue, _broker broker.Broker) bool {
 
 	// -- 3 step pub/sub attempt
 
@@ -40,7 +40,7 @@ func PublishReplayKline(orderId string, kline *d.Kline, queue *q.ReplayQueue, re
 	}
 
 	// 2. Attempting to publish order on Pub/Sub topic
-	if !PublishKline(orderId, kline, redis) {
+	if !PublishKline(orderId, kline, _broker) {
 		return false
 	}
 
@@ -53,7 +53,7 @@ func PublishReplayKline(orderId string, kline *d.Kline, queue *q.ReplayQueue, re
 }
 
 // PublishReplayEOF - Attempts to notify the client of the EOF for replay
-func PublishReplayEOF(orderId string, queue *q.ReplayQueue, redis *redis.Client) bool {
+func PublishReplayEOF(orderId string, queue *q.ReplayQueue, _broker broker.Broker) bool {
 
 	// -- 3 step pub/sub attempt
 
@@ -63,7 +63,30 @@ func PublishReplayEOF(orderId string, queue *q.ReplayQueue, redis *redis.Client)
 	}
 
 	// 2. Attempting to publish replay EOF on Pub/Sub topic
-	if !PublishEOF(orderId, redis) {
+	if !PublishEOF(orderId, _broker) {
+		return false
+	}
+
+	// 3. Marking this order as published
+	if !queue.Published(orderId) {
+		return false
+	}
+
+	return true
+}
+
+// PublishReplayLoop - Attempts to notify the client of replay starting over
+func PublishReplayLoop(orderId string, loop *d.Loop, queue *q.ReplayQueue, _broker broker.Broker) bool {
+
+	// -- 3 step pub/sub attempt
+
+	// 1. Asking queue whether we need to publish order or not
+	if !queue.CanPublish(orderId) {
+		return false
+	}
+
+	// 2. Attempting to publish replay loop marker on Pub/Sub topic
+	if !PublishLoop(orderId, loop, _broker) {
 		return false
 	}
 
@@ -6,14 +6,14 @@ import (
 	"log"
 	"strings"
 
+	"github.com/denniswon/tcex/app/broker"
 	d "github.com/denniswon/tcex/app/data"
 	q "github.com/denniswon/tcex/app/queue"
 	"github.com/gammazero/workerpool"
-	"github.com/go-redis/redis/v8"
 )
 
 // ProcessOrderReplays
-func ProcessOrderReplays(ctx context.Context, requestQueue *q.RequestQueue, replayQueue *q.ReplayQueue, redis *redis.Client) {
+func ProcessOrderReplays(ctx context.Context, requestQueue *q.RequestQueue, replayQueue *q.ReplayQueue, _broker broker.Broker) {
 
 	orderChan := make(chan q.Order)
 
@@ -23,113 +23,158 @@ func ProcessOrderReplays(ctx context.Context, requestQueue *q.RequestQueue, repl
 	// second start the replay queue as a separate go routine
 	go replayQueue.Start()
 
+	// Orders read from input file are submitted to replay queue,
+	// where they wait till they're due
+	go func() {
+
+		for {
+
+			select {
+
+			case <-ctx.Done():
+				return
+
+			case order := <-orderChan:
+
+				log.Println("Submitting order for replay", order)
+
+				replayQueue.Put(order)
+
+			}
+
+		}
+
+	}()
+
+	// Stopping both queues, lets publishers waiting for
+	// next due order know that they're supposed to exit
+	go func() {
+
+		<-ctx.Done()
+
+		log.Println("Exiting order replay publisher")
+
+		requestQueue.Close()
+		replayQueue.Stop()
+
+	}()
+
 	// TODO create a job queue of size `#-of CPUs present in machine` * concurrency factor for worker pool
 
 	// There is no upper limit on the number of tasks queued, other than the limits of system resources
 	// If the number of inbound tasks is too many to even queue for pending processing, then we should distribute workload over multiple systems,
 	// and/or storing input for pending processing in intermediate storage such as a distributed message queue, etc.
-	wp := workerpool.New( /* runtime.NumCPU() * int(cfg.GetConcurrencyFactor()) */ 1)
-	defer wp.Stop()
+	//
+	// Orders are published in order of their execution time, as long as there's single publisher
+	workers := 1 /* runtime.NumCPU() * int(cfg.GetConcurrencyFactor()) */
+	wp := workerpool.New(workers)
+	defer wp.StopWait()
 
-	for {
+	for i := 0; i < workers; i++ {
 
 		wp.Submit(func() {
 
 			for {
 
-				select {
-
-				case <-ctx.Done():
-
-					log.Println("Exiting order replay publisher")
-
-					requestQueue.Close()
-					replayQueue.Stop()
-					if orderChan != nil {
-						close(orderChan)
-						orderChan = nil
-					}
-
+				order, extime, eof, ok := replayQueue.PublishNext()
+				if !ok {
 					return
+				}
 
-				case order := <-orderChan:
+				PublishDue(order, extime, eof, requestQueue, replayQueue, _broker)
 
-					log.Println("Submitting order for replay", order)
+			}
 
-					replayQueue.Put(order)
+		})
 
-				default:
-					if order, extime, eof, ok := replayQueue.PublishNext(); ok {
+	}
+}
 
-						if eof {
+// PublishDue - Publishes order, which has become due for replay, using its
+// cached order data & removes that from cache, once published
+func PublishDue(order string, extime int64, eof bool, requestQueue *q.RequestQueue, replayQueue *q.ReplayQueue, _broker broker.Broker) {
 
-							log.Println("Publishing EOF for replay")
+	if eof {
 
-							if ok := PublishReplayEOF(order, replayQueue, redis); !ok {
-								log.Printf("Failed to publish replay eof %s\n", order)
-								continue
-							}
+		log.Println("Publishing EOF for replay")
 
-						} else {
+		if ok := PublishReplayEOF(order, replayQueue, _broker); !ok {
+			log.Printf("Failed to publish replay eof %s\n", order)
+			return
+		}
 
-							// retrieve the cached order data
-							encoded, err := redis.Get(context.Background(), order).Result()
-							if err != nil {
-								log.Printf("Failed to retrieve cached order %s : %s\n", order, err.Error())
-								continue
-							}
+	} else {
 
-							// kline data
-							if strings.Contains(encoded, "granularity") {
+		// retrieve the cached order data
+		encoded, err := _broker.Get(context.Background(), order)
+		if err != nil {
+			log.Printf("Failed to retrieve cached order %s : %s\n", order, err.Error())
+			return
+		}
 
-								_kline := d.Kline{}
-								err = json.Unmarshal([]byte(encoded), &_kline)
-								if err != nil {
-									log.Printf("Failed to unmarshal cached kline data for order id %s : %s\n", order, err.Error())
-									continue
-								}
+		// replay loop marker
+		if strings.Contains(encoded, `"loop"`) {
+
+			_loop := d.Loop{}
+			err = json.Unmarshal([]byte(encoded), &_loop)
+			if err != nil {
+				log.Printf("Failed to unmarshal cached loop marker for order id %s : %s\n", order, err.Error())
+				return
+			}
 
-								log.Printf("Publishing kline data for order id %s at time %d\n", order, extime)
+			log.Printf("Publishing loop marker for order id %s at time %d\n", order, extime)
 
-								if ok := PublishReplayKline(order, &_kline, replayQueue, redis); !ok {
-									log.Printf("Failed to publish replay kline data for order %s\n", order)
-									continue
-								}
+			if ok := PublishReplayLoop(order, &_loop, replayQueue, _broker); !ok {
+				log.Printf("Failed to publish replay loop marker for order %s\n", order)
+				return
+			}
 
-							} else {
+			// Replay is to be started over, right after this loop
+			if !requestQueue.Loop(_loop.RequestID) {
+				log.Printf("Failed to start replay over for request %s\n", _loop.RequestID)
+			}
 
-								_order := d.Order{}
-								err = json.Unmarshal([]byte(encoded), &_order)
-								if err != nil {
-									log.Printf("Failed to unmarshal cached order %s : %s\n", order, err.Error())
-									continue
-								}
+		} else if strings.Contains(encoded, "granularity") {
+			// kline data
 
-								log.Printf(
-									"Publishing order %s at time %d (order timestamp : %d)\n",
-									order, extime, _order.Timestamp,
-								)
+			_kline := d.Kline{}
+			err = json.Unmarshal([]byte(encoded), &_kline)
+			if err != nil {
+				log.Printf("Failed to unmarshal cached kline data for order id %s : %s\n", order, err.Error())
+				return
+			}
 
-								if ok := PublishReplayOrder(order, &_order, replayQueue, redis); !ok {
-									log.Printf("Failed to publish replay order %s\n", order)
-									continue
-								}
+			log.Printf("Publishing kline data for order id %s at time %d\n", order, extime)
 
-							}
+			if ok := PublishReplayKline(order, &_kline, replayQueue, _broker); !ok {
+				log.Printf("Failed to publish replay kline data for order %s\n", order)
+				return
+			}
 
-						}
+		} else {
 
-						res, err := redis.Del(context.Background(), order).Result()
-						if err != nil {
-							log.Printf("[!%d] Failed to delete cached order %s from redis : %s\n", res, order, err.Error())
-						}
-					}
+			_order := d.Order{}
+			err = json.Unmarshal([]byte(encoded), &_order)
+			if err != nil {
+				log.Printf("Failed to unmarshal cached order %s : %s\n", order, err.Error())
+				return
+			}
 
-				}
+			log.Printf(
+				"Publishing order %s at time %d (order timestamp : %d)\n",
+				order, extime, _order.Timestamp,
+			)
 
+			if ok := PublishReplayOrder(order, &_order, replayQueue, _broker); !ok {
+				log.Printf("Failed to publish replay order %s\n", order)
+				return
 			}
 
-		})
+		}
+
+	}
 
+	if err := _broker.Del(context.Background(), order); err != nil {
+		log.Printf("[!] Failed to delete cached order %s from cache : %s\n", order, err.Error())
 	}
 }
@@ -6,11 +6,11 @@ import (
 	"strings"
 
 	d "github.com/denniswon/tcex/app/data"
-	"github.com/go-redis/redis/v8"
+	"github.com/denniswon/tcex/app/broker"
 )
 
-// PublishOrder - Attempts to publish order data to Redis pubsub channel
-func PublishOrder(orderId string, order *d.Order, redis *redis.Client) bool {
+// PublishOrder - Attempts to publish order data to pubsub channel
+func PublishOrder(orderId string, order *d.Order, _broker broker.Broker) bool {
 
 	if order == nil {
 		return false
@@ -23,7 +23,7 @@ func PublishOrder(orderId string, order *d.Order, redis *redis.Client) bool {
 	}
 
 	requestId := tokens[0]
-	if err := redis.Publish(context.Background(), requestId, order).Err(); err != nil {
+	if err := _broker.Publish(context.Background(), requestId, order); err != nil {
 
 		log.Printf("Failed to publish order %s : %s\n", orderId, err.Error())
 		return false
@@ -35,8 +35,8 @@ func PublishOrder(orderId string, order *d.Order, redis *redis.Client) bool {
 
 }
 
-// PublishKline - Attempts to publish kline data to Redis pubsub channel
-func PublishKline(orderId string, kline *d.Kline, redis *redis.Client) bool {
+// PublishKline - Attempts to publish kline data to pubsub channel
+func PublishKline(orderId string, kline *d.Kline, _broker broker.Broker) bool {
 
 	if kline == nil {
 		return false
@@ -49,7 +49,7 @@ func PublishKline(orderId string, kline *d.Kline, redis *redis.Client) bool {
 	}
 
 	requestId := tokens[0]
-	if err := redis.Publish(context.Background(), requestId, kline).Err(); err != nil {
+	if err := _broker.Publish(context.Background(), requestId, kline); err != nil {
 
 		log.Printf("Failed to publish kline data for order %s : %s\n", orderId, err.Error())
 		return false
@@ -62,8 +62,8 @@ func PublishKline(orderId string, kline *d.Kline, redis *redis.Client) bool {
 }
 
 
-// PublishEOF - Attempts to publish replay eof to Redis pubsub channel
-func PublishEOF(orderId string, redis *redis.Client) bool {
+// PublishEOF - Attempts to publish replay eof to pubsub channel
+func PublishEOF(orderId string, _broker broker.Broker) bool {
 
 	tokens := strings.Split(orderId, ":")
 	if len(tokens) != 2 {
@@ -75,7 +75,7 @@ func PublishEOF(orderId string, redis *redis.Client) bool {
 	eof := d.EOF{
 		RequestID: requestId,
 	}
-	if err := redis.Publish(context.Background(), requestId, &eof).Err(); err != nil {
+	if err := _broker.Publish(context.Background(), requestId, &eof); err != nil {
 
 		log.Printf("Failed to publish eof %s : %s\n", requestId, err.Error())
 		return false
@@ -86,3 +86,29 @@ func PublishEOF(orderId string, redis *redis.Client) bool {
 	return true
 
 }
+
+// PublishLoop - Attempts to publish replay loop marker to pubsub channel
+func PublishLoop(orderId string, loop *d.Loop, _broker broker.Broker) bool {
+
+	if loop == nil {
+		return false
+	}
+
+	tokens := strings.Split(orderId, ":")
+	if len(tokens) != 2 {
+		log.Printf("Unexpected order id %s\n", orderId)
+		return false
+	}
+
+	requestId := tokens[0]
+	if err := _broker.Publish(context.Background(), requestId, loop); err != nil {
+
+		log.Printf("Failed to publish loop marker %s : %s\n", requestId, err.Error())
+		return false
+
+	}
+
+	log.Printf("📎 Published loop marker for request %s\n", requestId)
+	return true
+
+}
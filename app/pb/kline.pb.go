@@ -0,0 +1,288 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: kline.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Kline - OHLCV data for the orders of one bar
+type Kline struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp   int64   `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                        // bucket start time in unix timestamp
+	Low         float64 `protobuf:"fixed64,2,opt,name=low,proto3" json:"low,omitempty"`                                   // lowest price during the bucket interval
+	High        float64 `protobuf:"fixed64,3,opt,name=high,proto3" json:"high,omitempty"`                                 // highest price during the bucket interval
+	Open        float64 `protobuf:"fixed64,4,opt,name=open,proto3" json:"open,omitempty"`                                 // opening price (first trade) in the bucket interval
+	Close       float64 `protobuf:"fixed64,5,opt,name=close,proto3" json:"close,omitempty"`                               // closing price (last trade) in the bucket interval
+	Volume      int64   `protobuf:"varint,6,opt,name=volume,proto3" json:"volume,omitempty"`                              // net quantity volume of trading activity during the bucket interval
+	BuyVolume   uint64  `protobuf:"varint,7,opt,name=buy_volume,json=buyVolume,proto3" json:"buy_volume,omitempty"`       // quantity bought by aggressors during the bucket interval
+	SellVolume  uint64  `protobuf:"varint,8,opt,name=sell_volume,json=sellVolume,proto3" json:"sell_volume,omitempty"`    // quantity sold by aggressors during the bucket interval
+	GrossVolume uint64  `protobuf:"varint,9,opt,name=gross_volume,json=grossVolume,proto3" json:"gross_volume,omitempty"` // total quantity traded during the bucket interval
+	Trades      uint64  `protobuf:"varint,10,opt,name=trades,proto3" json:"trades,omitempty"`                             // number of trades during the bucket interval
+	Turnover    float64 `protobuf:"fixed64,11,opt,name=turnover,proto3" json:"turnover,omitempty"`                        // total usd volume of trading activity during the bucket interval
+	Vwap        float64 `protobuf:"fixed64,12,opt,name=vwap,proto3" json:"vwap,omitempty"`                                // volume weighted average price during the bucket interval, 0 if nothing traded
+	Granularity uint32  `protobuf:"varint,13,opt,name=granularity,proto3" json:"granularity,omitempty"`                   // granularity field is in "seconds", 0 for bars other than time buckets
+	BarType     string  `protobuf:"bytes,14,opt,name=bar_type,json=barType,proto3" json:"bar_type,omitempty"`             // "time", "tick", "volume" or "dollar"
+	Threshold   float64 `protobuf:"fixed64,15,opt,name=threshold,proto3" json:"threshold,omitempty"`                      // # of trades, quantity or turnover closing the bar, 0 for time buckets
+	Closed      bool    `protobuf:"varint,16,opt,name=closed,proto3" json:"closed,omitempty"`                             // whether bucket interval is over, so that it won't change anymore
+}
+
+func (x *Kline) Reset() {
+	*x = Kline{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_kline_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Kline) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Kline) ProtoMessage() {}
+
+func (x *Kline) ProtoReflect() protoreflect.Message {
+	mi := &file_kline_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Kline.ProtoReflect.Descriptor instead.
+func (*Kline) Descriptor() ([]byte, []int) {
+	return file_kline_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Kline) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Kline) GetLow() float64 {
+	if x != nil {
+		return x.Low
+	}
+	return 0
+}
+
+func (x *Kline) GetHigh() float64 {
+	if x != nil {
+		return x.High
+	}
+	return 0
+}
+
+func (x *Kline) GetOpen() float64 {
+	if x != nil {
+		return x.Open
+	}
+	return 0
+}
+
+func (x *Kline) GetClose() float64 {
+	if x != nil {
+		return x.Close
+	}
+	return 0
+}
+
+func (x *Kline) GetVolume() int64 {
+	if x != nil {
+		return x.Volume
+	}
+	return 0
+}
+
+func (x *Kline) GetBuyVolume() uint64 {
+	if x != nil {
+		return x.BuyVolume
+	}
+	return 0
+}
+
+func (x *Kline) GetSellVolume() uint64 {
+	if x != nil {
+		return x.SellVolume
+	}
+	return 0
+}
+
+func (x *Kline) GetGrossVolume() uint64 {
+	if x != nil {
+		return x.GrossVolume
+	}
+	return 0
+}
+
+func (x *Kline) GetTrades() uint64 {
+	if x != nil {
+		return x.Trades
+	}
+	return 0
+}
+
+func (x *Kline) GetTurnover() float64 {
+	if x != nil {
+		return x.Turnover
+	}
+	return 0
+}
+
+func (x *Kline) GetVwap() float64 {
+	if x != nil {
+		return x.Vwap
+	}
+	return 0
+}
+
+func (x *Kline) GetGranularity() uint32 {
+	if x != nil {
+		return x.Granularity
+	}
+	return 0
+}
+
+func (x *Kline) GetBarType() string {
+	if x != nil {
+		return x.BarType
+	}
+	return ""
+}
+
+func (x *Kline) GetThreshold() float64 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *Kline) GetClosed() bool {
+	if x != nil {
+		return x.Closed
+	}
+	return false
+}
+
+var File_kline_proto protoreflect.FileDescriptor
+
+var file_kline_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x6b, 0x6c, 0x69, 0x6e, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04, 0x74,
+	0x63, 0x65, 0x78, 0x22, 0xab, 0x03, 0x0a, 0x05, 0x4b, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x1c, 0x0a,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x10, 0x0a, 0x03, 0x6c,
+	0x6f, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x6c, 0x6f, 0x77, 0x12, 0x12, 0x0a,
+	0x04, 0x68, 0x69, 0x67, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x68, 0x69, 0x67,
+	0x68, 0x12, 0x12, 0x0a, 0x04, 0x6f, 0x70, 0x65, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x04, 0x6f, 0x70, 0x65, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x76,
+	0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x76, 0x6f, 0x6c,
+	0x75, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x75, 0x79, 0x5f, 0x76, 0x6f, 0x6c, 0x75, 0x6d,
+	0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x62, 0x75, 0x79, 0x56, 0x6f, 0x6c, 0x75,
+	0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x6c, 0x6c, 0x5f, 0x76, 0x6f, 0x6c, 0x75, 0x6d,
+	0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x73, 0x65, 0x6c, 0x6c, 0x56, 0x6f, 0x6c,
+	0x75, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x67, 0x72, 0x6f, 0x73, 0x73, 0x5f, 0x76, 0x6f, 0x6c,
+	0x75, 0x6d, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x67, 0x72, 0x6f, 0x73, 0x73,
+	0x56, 0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x72, 0x61, 0x64, 0x65, 0x73,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x74, 0x72, 0x61, 0x64, 0x65, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x74, 0x75, 0x72, 0x6e, 0x6f, 0x76, 0x65, 0x72, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x08, 0x74, 0x75, 0x72, 0x6e, 0x6f, 0x76, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x76, 0x77,
+	0x61, 0x70, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x76, 0x77, 0x61, 0x70, 0x12, 0x20,
+	0x0a, 0x0b, 0x67, 0x72, 0x61, 0x6e, 0x75, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x18, 0x0d, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x0b, 0x67, 0x72, 0x61, 0x6e, 0x75, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79,
+	0x12, 0x19, 0x0a, 0x08, 0x62, 0x61, 0x72, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x62, 0x61, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74,
+	0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09,
+	0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6c, 0x6f,
+	0x73, 0x65, 0x64, 0x18, 0x10, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x63, 0x6c, 0x6f, 0x73, 0x65,
+	0x64, 0x42, 0x22, 0x5a, 0x20, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x64, 0x65, 0x6e, 0x6e, 0x69, 0x73, 0x77, 0x6f, 0x6e, 0x2f, 0x74, 0x63, 0x65, 0x78, 0x2f, 0x61,
+	0x70, 0x70, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_kline_proto_rawDescOnce sync.Once
+	file_kline_proto_rawDescData = file_kline_proto_rawDesc
+)
+
+func file_kline_proto_rawDescGZIP() []byte {
+	file_kline_proto_rawDescOnce.Do(func() {
+		file_kline_proto_rawDescData = protoimpl.X.CompressGZIP(file_kline_proto_rawDescData)
+	})
+	return file_kline_proto_rawDescData
+}
+
+var file_kline_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_kline_proto_goTypes = []any{
+	(*Kline)(nil), // 0: tcex.Kline
+}
+var file_kline_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_kline_proto_init() }
+func file_kline_proto_init() {
+	if File_kline_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_kline_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Kline); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_kline_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_kline_proto_goTypes,
+		DependencyIndexes: file_kline_proto_depIdxs,
+		MessageInfos:      file_kline_proto_msgTypes,
+	}.Build()
+	File_kline_proto = out.File
+	file_kline_proto_rawDesc = nil
+	file_kline_proto_goTypes = nil
+	file_kline_proto_depIdxs = nil
+}
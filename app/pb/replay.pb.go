@@ -0,0 +1,724 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: replay.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// EOF - Sent once replay is over
+type EOF struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+}
+
+func (x *EOF) Reset() {
+	*x = EOF{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_replay_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EOF) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EOF) ProtoMessage() {}
+
+func (x *EOF) ProtoReflect() protoreflect.Message {
+	mi := &file_replay_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EOF.ProtoReflect.Descriptor instead.
+func (*EOF) Descriptor() ([]byte, []int) {
+	return file_replay_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EOF) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+// Loop - Sent in place of EOF, whenever replay starts over
+type Loop struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Loop      uint64 `protobuf:"varint,2,opt,name=loop,proto3" json:"loop,omitempty"` // number of loops replayed so far
+}
+
+func (x *Loop) Reset() {
+	*x = Loop{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_replay_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Loop) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Loop) ProtoMessage() {}
+
+func (x *Loop) ProtoReflect() protoreflect.Message {
+	mi := &file_replay_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Loop.ProtoReflect.Descriptor instead.
+func (*Loop) Descriptor() ([]byte, []int) {
+	return file_replay_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Loop) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *Loop) GetLoop() uint64 {
+	if x != nil {
+		return x.Loop
+	}
+	return 0
+}
+
+// SubscriptionResponse - Outcome of subscription & control requests
+type SubscriptionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code uint32 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"` // 1 on success, 0 otherwise
+	Id   string `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Msg  string `protobuf:"bytes,3,opt,name=msg,proto3" json:"msg,omitempty"`
+}
+
+func (x *SubscriptionResponse) Reset() {
+	*x = SubscriptionResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_replay_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionResponse) ProtoMessage() {}
+
+func (x *SubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_replay_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*SubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_replay_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SubscriptionResponse) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *SubscriptionResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SubscriptionResponse) GetMsg() string {
+	if x != nil {
+		return x.Msg
+	}
+	return ""
+}
+
+// Frame - Every binary websocket frame delivered to client application
+// carries exactly one of these
+type Frame struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Payload:
+	//	*Frame_Order
+	//	*Frame_Kline
+	//	*Frame_Eof
+	//	*Frame_Loop
+	//	*Frame_Response
+	Payload isFrame_Payload `protobuf_oneof:"payload"`
+}
+
+func (x *Frame) Reset() {
+	*x = Frame{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_replay_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Frame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Frame) ProtoMessage() {}
+
+func (x *Frame) ProtoReflect() protoreflect.Message {
+	mi := &file_replay_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Frame.ProtoReflect.Descriptor instead.
+func (*Frame) Descriptor() ([]byte, []int) {
+	return file_replay_proto_rawDescGZIP(), []int{3}
+}
+
+func (m *Frame) GetPayload() isFrame_Payload {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (x *Frame) GetOrder() *Order {
+	if x, ok := x.GetPayload().(*Frame_Order); ok {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *Frame) GetKline() *Kline {
+	if x, ok := x.GetPayload().(*Frame_Kline); ok {
+		return x.Kline
+	}
+	return nil
+}
+
+func (x *Frame) GetEof() *EOF {
+	if x, ok := x.GetPayload().(*Frame_Eof); ok {
+		return x.Eof
+	}
+	return nil
+}
+
+func (x *Frame) GetLoop() *Loop {
+	if x, ok := x.GetPayload().(*Frame_Loop); ok {
+		return x.Loop
+	}
+	return nil
+}
+
+func (x *Frame) GetResponse() *SubscriptionResponse {
+	if x, ok := x.GetPayload().(*Frame_Response); ok {
+		return x.Response
+	}
+	return nil
+}
+
+type isFrame_Payload interface {
+	isFrame_Payload()
+}
+
+type Frame_Order struct {
+	Order *Order `protobuf:"bytes,1,opt,name=order,proto3,oneof"`
+}
+
+type Frame_Kline struct {
+	Kline *Kline `protobuf:"bytes,2,opt,name=kline,proto3,oneof"`
+}
+
+type Frame_Eof struct {
+	Eof *EOF `protobuf:"bytes,3,opt,name=eof,proto3,oneof"`
+}
+
+type Frame_Loop struct {
+	Loop *Loop `protobuf:"bytes,4,opt,name=loop,proto3,oneof"`
+}
+
+type Frame_Response struct {
+	Response *SubscriptionResponse `protobuf:"bytes,5,opt,name=response,proto3,oneof"`
+}
+
+func (*Frame_Order) isFrame_Payload() {}
+
+func (*Frame_Kline) isFrame_Payload() {}
+
+func (*Frame_Eof) isFrame_Payload() {}
+
+func (*Frame_Loop) isFrame_Payload() {}
+
+func (*Frame_Response) isFrame_Payload() {}
+
+// Request - Subscription & control requests, to be sent as binary websocket
+// frame, holding same fields as JSON requests do
+type Request struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type          string            `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "subscribe", "unsubscribe", "pause", "resume", "stop", "set_rate" or "seek"
+	Id            string            `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Filename      string            `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`
+	ReplayRate    float32           `protobuf:"fixed32,4,opt,name=replay_rate,json=replayRate,proto3" json:"replay_rate,omitempty"`
+	Name          string            `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"` // "order" or "kline"
+	Granularity   uint32            `protobuf:"varint,6,opt,name=granularity,proto3" json:"granularity,omitempty"`
+	Granularities []uint32          `protobuf:"varint,7,rep,packed,name=granularities,proto3" json:"granularities,omitempty"`
+	StartTime     int64             `protobuf:"varint,8,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       int64             `protobuf:"varint,9,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	WarmupFrom    int64             `protobuf:"varint,10,opt,name=warmup_from,json=warmupFrom,proto3" json:"warmup_from,omitempty"`
+	FillGaps      bool              `protobuf:"varint,11,opt,name=fill_gaps,json=fillGaps,proto3" json:"fill_gaps,omitempty"`
+	BarType       string            `protobuf:"bytes,12,opt,name=bar_type,json=barType,proto3" json:"bar_type,omitempty"`
+	Threshold     float64           `protobuf:"fixed64,13,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	Loop          int64             `protobuf:"varint,14,opt,name=loop,proto3" json:"loop,omitempty"` // -1 for looping endlessly
+	Timestamp     int64             `protobuf:"varint,15,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Format        string            `protobuf:"bytes,16,opt,name=format,proto3" json:"format,omitempty"`                                                                                           // "json" or "protobuf"
+	FileFormat    string            `protobuf:"bytes,17,opt,name=file_format,json=fileFormat,proto3" json:"file_format,omitempty"`                                                                 // "jsonl", "csv" or "tsv", detected if empty
+	TimestampUnit string            `protobuf:"bytes,18,opt,name=timestamp_unit,json=timestampUnit,proto3" json:"timestamp_unit,omitempty"`                                                        // "s", "ms", "us" or "ns"
+	Columns       map[string]string `protobuf:"bytes,19,rep,name=columns,proto3" json:"columns,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"` // header name of each trade field in csv & tsv files
+	Dataset       string            `protobuf:"bytes,20,opt,name=dataset,proto3" json:"dataset,omitempty"`                                                                                         // id of uploaded dataset, replayed instead of filename
+}
+
+func (x *Request) Reset() {
+	*x = Request{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_replay_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Request) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request) ProtoMessage() {}
+
+func (x *Request) ProtoReflect() protoreflect.Message {
+	mi := &file_replay_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request.ProtoReflect.Descriptor instead.
+func (*Request) Descriptor() ([]byte, []int) {
+	return file_replay_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Request) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Request) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Request) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *Request) GetReplayRate() float32 {
+	if x != nil {
+		return x.ReplayRate
+	}
+	return 0
+}
+
+func (x *Request) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Request) GetGranularity() uint32 {
+	if x != nil {
+		return x.Granularity
+	}
+	return 0
+}
+
+func (x *Request) GetGranularities() []uint32 {
+	if x != nil {
+		return x.Granularities
+	}
+	return nil
+}
+
+func (x *Request) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *Request) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *Request) GetWarmupFrom() int64 {
+	if x != nil {
+		return x.WarmupFrom
+	}
+	return 0
+}
+
+func (x *Request) GetFillGaps() bool {
+	if x != nil {
+		return x.FillGaps
+	}
+	return false
+}
+
+func (x *Request) GetBarType() string {
+	if x != nil {
+		return x.BarType
+	}
+	return ""
+}
+
+func (x *Request) GetThreshold() float64 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *Request) GetLoop() int64 {
+	if x != nil {
+		return x.Loop
+	}
+	return 0
+}
+
+func (x *Request) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Request) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *Request) GetFileFormat() string {
+	if x != nil {
+		return x.FileFormat
+	}
+	return ""
+}
+
+func (x *Request) GetTimestampUnit() string {
+	if x != nil {
+		return x.TimestampUnit
+	}
+	return ""
+}
+
+func (x *Request) GetColumns() map[string]string {
+	if x != nil {
+		return x.Columns
+	}
+	return nil
+}
+
+func (x *Request) GetDataset() string {
+	if x != nil {
+		return x.Dataset
+	}
+	return ""
+}
+
+var File_replay_proto protoreflect.FileDescriptor
+
+var file_replay_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04,
+	0x74, 0x63, 0x65, 0x78, 0x1a, 0x0b, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x1a, 0x0b, 0x6b, 0x6c, 0x69, 0x6e, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x24,
+	0x0a, 0x03, 0x45, 0x4f, 0x46, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x49, 0x64, 0x22, 0x39, 0x0a, 0x04, 0x4c, 0x6f, 0x6f, 0x70, 0x12, 0x1d, 0x0a, 0x0a,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6c,
+	0x6f, 0x6f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x6c, 0x6f, 0x6f, 0x70, 0x22,
+	0x4c, 0x0a, 0x14, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x6d,
+	0x73, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x22, 0xd7, 0x01,
+	0x0a, 0x05, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a, 0x05, 0x6f, 0x72, 0x64, 0x65, 0x72,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x74, 0x63, 0x65, 0x78, 0x2e, 0x4f, 0x72,
+	0x64, 0x65, 0x72, 0x48, 0x00, 0x52, 0x05, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x05,
+	0x6b, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x74, 0x63,
+	0x65, 0x78, 0x2e, 0x4b, 0x6c, 0x69, 0x6e, 0x65, 0x48, 0x00, 0x52, 0x05, 0x6b, 0x6c, 0x69, 0x6e,
+	0x65, 0x12, 0x1d, 0x0a, 0x03, 0x65, 0x6f, 0x66, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09,
+	0x2e, 0x74, 0x63, 0x65, 0x78, 0x2e, 0x45, 0x4f, 0x46, 0x48, 0x00, 0x52, 0x03, 0x65, 0x6f, 0x66,
+	0x12, 0x20, 0x0a, 0x04, 0x6c, 0x6f, 0x6f, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a,
+	0x2e, 0x74, 0x63, 0x65, 0x78, 0x2e, 0x4c, 0x6f, 0x6f, 0x70, 0x48, 0x00, 0x52, 0x04, 0x6c, 0x6f,
+	0x6f, 0x70, 0x12, 0x38, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x74, 0x63, 0x65, 0x78, 0x2e, 0x53, 0x75, 0x62, 0x73,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x48, 0x00, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x09, 0x0a, 0x07,
+	0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x95, 0x05, 0x0a, 0x07, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x5f, 0x72, 0x61,
+	0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x79,
+	0x52, 0x61, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x67, 0x72, 0x61, 0x6e,
+	0x75, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x67,
+	0x72, 0x61, 0x6e, 0x75, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x79, 0x12, 0x24, 0x0a, 0x0d, 0x67, 0x72,
+	0x61, 0x6e, 0x75, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28,
+	0x0d, 0x52, 0x0d, 0x67, 0x72, 0x61, 0x6e, 0x75, 0x6c, 0x61, 0x72, 0x69, 0x74, 0x69, 0x65, 0x73,
+	0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12,
+	0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x77, 0x61,
+	0x72, 0x6d, 0x75, 0x70, 0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0a, 0x77, 0x61, 0x72, 0x6d, 0x75, 0x70, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x1b, 0x0a, 0x09, 0x66,
+	0x69, 0x6c, 0x6c, 0x5f, 0x67, 0x61, 0x70, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08,
+	0x66, 0x69, 0x6c, 0x6c, 0x47, 0x61, 0x70, 0x73, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x61, 0x72, 0x5f,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x62, 0x61, 0x72, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64,
+	0x18, 0x0d, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c,
+	0x64, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x6f, 0x6f, 0x70, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x04, 0x6c, 0x6f, 0x6f, 0x70, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x10, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x66,
+	0x69, 0x6c, 0x65, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x11, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x66, 0x69, 0x6c, 0x65, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x25, 0x0a, 0x0e,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x5f, 0x75, 0x6e, 0x69, 0x74, 0x18, 0x12,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x55,
+	0x6e, 0x69, 0x74, 0x12, 0x34, 0x0a, 0x07, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x18, 0x13,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x74, 0x63, 0x65, 0x78, 0x2e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x2e, 0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x07, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x61, 0x74,
+	0x61, 0x73, 0x65, 0x74, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x64, 0x61, 0x74, 0x61,
+	0x73, 0x65, 0x74, 0x1a, 0x3a, 0x0a, 0x0c, 0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42,
+	0x22, 0x5a, 0x20, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x64, 0x65,
+	0x6e, 0x6e, 0x69, 0x73, 0x77, 0x6f, 0x6e, 0x2f, 0x74, 0x63, 0x65, 0x78, 0x2f, 0x61, 0x70, 0x70,
+	0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_replay_proto_rawDescOnce sync.Once
+	file_replay_proto_rawDescData = file_replay_proto_rawDesc
+)
+
+func file_replay_proto_rawDescGZIP() []byte {
+	file_replay_proto_rawDescOnce.Do(func() {
+		file_replay_proto_rawDescData = protoimpl.X.CompressGZIP(file_replay_proto_rawDescData)
+	})
+	return file_replay_proto_rawDescData
+}
+
+var file_replay_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_replay_proto_goTypes = []any{
+	(*EOF)(nil),                  // 0: tcex.EOF
+	(*Loop)(nil),                 // 1: tcex.Loop
+	(*SubscriptionResponse)(nil), // 2: tcex.SubscriptionResponse
+	(*Frame)(nil),                // 3: tcex.Frame
+	(*Request)(nil),              // 4: tcex.Request
+	nil,                          // 5: tcex.Request.ColumnsEntry
+	(*Order)(nil),                // 6: tcex.Order
+	(*Kline)(nil),                // 7: tcex.Kline
+}
+var file_replay_proto_depIdxs = []int32{
+	6, // 0: tcex.Frame.order:type_name -> tcex.Order
+	7, // 1: tcex.Frame.kline:type_name -> tcex.Kline
+	0, // 2: tcex.Frame.eof:type_name -> tcex.EOF
+	1, // 3: tcex.Frame.loop:type_name -> tcex.Loop
+	2, // 4: tcex.Frame.response:type_name -> tcex.SubscriptionResponse
+	5, // 5: tcex.Request.columns:type_name -> tcex.Request.ColumnsEntry
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_replay_proto_init() }
+func file_replay_proto_init() {
+	if File_replay_proto != nil {
+		return
+	}
+	file_order_proto_init()
+	file_kline_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_replay_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*EOF); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_replay_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Loop); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_replay_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*SubscriptionResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_replay_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*Frame); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_replay_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*Request); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_replay_proto_msgTypes[3].OneofWrappers = []any{
+		(*Frame_Order)(nil),
+		(*Frame_Kline)(nil),
+		(*Frame_Eof)(nil),
+		(*Frame_Loop)(nil),
+		(*Frame_Response)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_replay_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_replay_proto_goTypes,
+		DependencyIndexes: file_replay_proto_depIdxs,
+		MessageInfos:      file_replay_proto_msgTypes,
+	}.Build()
+	File_replay_proto = out.File
+	file_replay_proto_rawDesc = nil
+	file_replay_proto_goTypes = nil
+	file_replay_proto_depIdxs = nil
+}
@@ -0,0 +1,170 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: service.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Replayer_Replay_FullMethodName  = "/tcex.Replayer/Replay"
+	Replayer_Control_FullMethodName = "/tcex.Replayer/Control"
+)
+
+// ReplayerClient is the client API for Replayer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Replayer - Same replays as over websocket, for clients preferring typed,
+// generated stubs
+type ReplayerClient interface {
+	// Replay - Streams replay of subscription request, until its EOF
+	Replay(ctx context.Context, in *Request, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Frame], error)
+	// Control - Streams replay of subscription request sent first, where requests
+	// sent afterwards control the replay i.e. "pause", "resume", "stop", "set_rate",
+	// "seek" or "unsubscribe", until its EOF
+	Control(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Request, Frame], error)
+}
+
+type replayerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReplayerClient(cc grpc.ClientConnInterface) ReplayerClient {
+	return &replayerClient{cc}
+}
+
+func (c *replayerClient) Replay(ctx context.Context, in *Request, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Frame], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Replayer_ServiceDesc.Streams[0], Replayer_Replay_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Request, Frame]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Replayer_ReplayClient = grpc.ServerStreamingClient[Frame]
+
+func (c *replayerClient) Control(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Request, Frame], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Replayer_ServiceDesc.Streams[1], Replayer_Control_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Request, Frame]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Replayer_ControlClient = grpc.BidiStreamingClient[Request, Frame]
+
+// ReplayerServer is the server API for Replayer service.
+// All implementations must embed UnimplementedReplayerServer
+// for forward compatibility.
+//
+// Replayer - Same replays as over websocket, for clients preferring typed,
+// generated stubs
+type ReplayerServer interface {
+	// Replay - Streams replay of subscription request, until its EOF
+	Replay(*Request, grpc.ServerStreamingServer[Frame]) error
+	// Control - Streams replay of subscription request sent first, where requests
+	// sent afterwards control the replay i.e. "pause", "resume", "stop", "set_rate",
+	// "seek" or "unsubscribe", until its EOF
+	Control(grpc.BidiStreamingServer[Request, Frame]) error
+	mustEmbedUnimplementedReplayerServer()
+}
+
+// UnimplementedReplayerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedReplayerServer struct{}
+
+func (UnimplementedReplayerServer) Replay(*Request, grpc.ServerStreamingServer[Frame]) error {
+	return status.Errorf(codes.Unimplemented, "method Replay not implemented")
+}
+func (UnimplementedReplayerServer) Control(grpc.BidiStreamingServer[Request, Frame]) error {
+	return status.Errorf(codes.Unimplemented, "method Control not implemented")
+}
+func (UnimplementedReplayerServer) mustEmbedUnimplementedReplayerServer() {}
+func (UnimplementedReplayerServer) testEmbeddedByValue()                  {}
+
+// UnsafeReplayerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReplayerServer will
+// result in compilation errors.
+type UnsafeReplayerServer interface {
+	mustEmbedUnimplementedReplayerServer()
+}
+
+func RegisterReplayerServer(s grpc.ServiceRegistrar, srv ReplayerServer) {
+	// If the following call pancis, it indicates UnimplementedReplayerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Replayer_ServiceDesc, srv)
+}
+
+func _Replayer_Replay_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Request)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReplayerServer).Replay(m, &grpc.GenericServerStream[Request, Frame]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Replayer_ReplayServer = grpc.ServerStreamingServer[Frame]
+
+func _Replayer_Control_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ReplayerServer).Control(&grpc.GenericServerStream[Request, Frame]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Replayer_ControlServer = grpc.BidiStreamingServer[Request, Frame]
+
+// Replayer_ServiceDesc is the grpc.ServiceDesc for Replayer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Replayer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tcex.Replayer",
+	HandlerType: (*ReplayerServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Replay",
+			Handler:       _Replayer_Replay_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Control",
+			Handler:       _Replayer_Control_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "service.proto",
+}
@@ -3,10 +3,39 @@ package pubsub
 import (
 	"sync"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/gorilla/websocket"
+	"github.com/denniswon/tcex/app/broker"
 )
 
+// Connection - Network connection to client application, which consumers deliver
+// data over, i.e. websocket connection or server-sent event stream
+type Connection interface {
+	WriteMessage(messageType int, data []byte) error
+}
+
+// DataConnection - Connection, which takes data to be delivered as it is, instead of encoded
+// frames, i.e. gRPC stream, which sends protobuf messages, so that it's not encoded twice, or
+// http stream, which tells replay EOF apart by its type
+type DataConnection interface {
+	Connection
+	WriteData(data interface{}) error
+}
+
+// deliver - Writes data to given connection, encoded in given format, unless
+// connection takes data as it is
+func deliver(conn Connection, format string, data interface{}) error {
+
+	if _conn, ok := conn.(DataConnection); ok {
+		return _conn.WriteData(data)
+	}
+
+	messageType, _data, err := Frame(format, data)
+	if err != nil {
+		return err
+	}
+
+	return conn.WriteMessage(messageType, _data)
+}
+
 // Consumer - Order, transaction & event consumers need to implement these methods
 type Consumer interface {
 	Subscribe()
@@ -19,9 +48,9 @@ type Consumer interface {
 // NewOrderConsumer - Creating one new order data consumer, which will subscribe to order
 // topic & listen for data being published on this channel, which will eventually be
 // delivered to client application over websocket connection
-func NewOrderConsumer(client *redis.Client, request *SubscriptionRequest, conn *websocket.Conn, connLock *sync.Mutex, topicLock *sync.RWMutex) *OrderConsumer {
+func NewOrderConsumer(_broker broker.Broker, request *SubscriptionRequest, conn Connection, connLock *sync.Mutex, topicLock *sync.RWMutex) *OrderConsumer {
 	consumer := OrderConsumer{
-		Client:     client,
+		Broker:     _broker,
 		Request:   	request,
 		Connection: conn,
 		ConnLock:   connLock,
@@ -37,9 +66,9 @@ func NewOrderConsumer(client *redis.Client, request *SubscriptionRequest, conn *
 // NewKlineConsumer - Creating one new kline data consumer, which will subscribe to order
 // topic & listen for data being published on this channel, which will eventually be
 // delivered to client application over websocket connection
-func NewKlineConsumer(client *redis.Client, request *SubscriptionRequest, conn *websocket.Conn, connLock *sync.Mutex, topicLock *sync.RWMutex) *KlineConsumer {
+func NewKlineConsumer(_broker broker.Broker, request *SubscriptionRequest, conn Connection, connLock *sync.Mutex, topicLock *sync.RWMutex) *KlineConsumer {
 	consumer := KlineConsumer{
-		Client:     client,
+		Broker:     _broker,
 		Request:   	request,
 		Connection: conn,
 		ConnLock:   connLock,
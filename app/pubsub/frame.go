@@ -0,0 +1,149 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	d "github.com/denniswon/tcex/app/data"
+	"github.com/denniswon/tcex/app/pb"
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+)
+
+// Frame - Encodes data to be delivered to client application over websocket, in format
+// client asked for while subscribing, along with websocket message type to be used
+//
+// JSON is sent as text message, while protobuf is sent as binary message holding `pb.Frame`
+func Frame(format string, data interface{}) (int, []byte, error) {
+
+	if format != "protobuf" {
+
+		_data, err := json.Marshal(data)
+		return websocket.TextMessage, _data, err
+
+	}
+
+	frame, err := ProtoFrame(data)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	_data, err := proto.Marshal(frame)
+	return websocket.BinaryMessage, _data, err
+}
+
+// ProtoFrame - Wraps data to be delivered to client application in `pb.Frame`,
+// which carries exactly one of replayed data, loop marker, EOF or response
+func ProtoFrame(data interface{}) (*pb.Frame, error) {
+
+	frame := &pb.Frame{}
+
+	switch v := data.(type) {
+
+	case *d.Order:
+		frame.Payload = &pb.Frame_Order{Order: &pb.Order{
+			Price:     v.Price,
+			Quantity:  v.Quantity,
+			Aggressor: v.Aggressor,
+			Timestamp: v.Timestamp,
+		}}
+
+	case *d.Kline:
+		frame.Payload = &pb.Frame_Kline{Kline: &pb.Kline{
+			Timestamp:   v.Timestamp,
+			Low:         v.Low,
+			High:        v.High,
+			Open:        v.Open,
+			Close:       v.Close,
+			Volume:      v.Volume,
+			BuyVolume:   v.BuyVolume,
+			SellVolume:  v.SellVolume,
+			GrossVolume: v.GrossVolume,
+			Trades:      v.Trades,
+			Turnover:    v.Turnover,
+			Vwap:        v.VWAP,
+			Granularity: uint32(v.Granularity),
+			BarType:     v.BarType,
+			Threshold:   v.Threshold,
+			Closed:      v.Closed,
+		}}
+
+	case *d.EOF:
+		frame.Payload = &pb.Frame_Eof{Eof: &pb.EOF{RequestId: v.RequestID}}
+
+	case *d.Loop:
+		frame.Payload = &pb.Frame_Loop{Loop: &pb.Loop{RequestId: v.RequestID, Loop: v.Loop}}
+
+	case *SubscriptionResponse:
+		frame.Payload = &pb.Frame_Response{Response: &pb.SubscriptionResponse{
+			Code: uint32(v.Code),
+			Id:   v.ID,
+			Msg:  v.Message,
+		}}
+
+	default:
+		return nil, fmt.Errorf("no protobuf frame for %T", data)
+
+	}
+
+	return frame, nil
+}
+
+// Decode - Decodes subscription/ control request received from client application,
+// either as JSON text message or as binary message holding `pb.Request`
+func Decode(messageType int, data []byte, req *SubscriptionRequest) error {
+
+	if messageType != websocket.BinaryMessage {
+		return json.Unmarshal(data, req)
+	}
+
+	var _req pb.Request
+	if err := proto.Unmarshal(data, &_req); err != nil {
+		return err
+	}
+
+	return FromProto(&_req, req)
+}
+
+// FromProto - Fills in subscription/ control request from its protobuf counterpart
+func FromProto(_req *pb.Request, req *SubscriptionRequest) error {
+
+	if _req.Granularity > math.MaxUint16 {
+		return fmt.Errorf("granularity %d out of range", _req.Granularity)
+	}
+
+	granularities := make([]uint16, 0, len(_req.Granularities))
+	for _, granularity := range _req.Granularities {
+		if granularity > math.MaxUint16 {
+			return fmt.Errorf("granularity %d out of range", granularity)
+		}
+
+		granularities = append(granularities, uint16(granularity))
+	}
+
+	*req = SubscriptionRequest{
+		ID:            _req.Id,
+		Filename:      _req.Filename,
+		ReplayRate:    _req.ReplayRate,
+		Type:          _req.Type,
+		Name:          _req.Name,
+		Granularity:   uint16(_req.Granularity),
+		Granularities: granularities,
+		StartTime:     _req.StartTime,
+		EndTime:       _req.EndTime,
+		WarmupFrom:    _req.WarmupFrom,
+		FillGaps:      _req.FillGaps,
+		BarType:       _req.BarType,
+		Threshold:     _req.Threshold,
+		Loop:          Loop(_req.Loop),
+		Timestamp:     _req.Timestamp,
+		Format:        _req.Format,
+		FileFormat:    _req.FileFormat,
+		TimestampUnit: _req.TimestampUnit,
+		Columns:       _req.Columns,
+		Dataset:       _req.Dataset,
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package pubsub
+
+import (
+	"testing"
+
+	d "github.com/denniswon/tcex/app/data"
+	"github.com/denniswon/tcex/app/pb"
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+)
+
+// messages - Connection, which keeps messages written to it
+type messages struct {
+	types []int
+	data  [][]byte
+}
+
+func (m *messages) WriteMessage(messageType int, data []byte) error {
+	m.types = append(m.types, messageType)
+	m.data = append(m.data, data)
+	return nil
+}
+
+// values - Connection, which keeps data written to it as it is
+type values struct {
+	messages
+	data []interface{}
+}
+
+func (v *values) WriteData(data interface{}) error {
+	v.data = append(v.data, data)
+	return nil
+}
+
+func TestProtoFrame(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		data  interface{}
+		check func(*pb.Frame) bool
+	}{
+		{"order", &d.Order{Price: "1.5", Timestamp: 1}, func(f *pb.Frame) bool { return f.GetOrder().GetPrice() == "1.5" }},
+		{"kline", &d.Kline{Granularity: 60, Closed: true}, func(f *pb.Frame) bool { return f.GetKline().GetGranularity() == 60 && f.GetKline().GetClosed() }},
+		{"eof", &d.EOF{RequestID: "req"}, func(f *pb.Frame) bool { return f.GetEof().GetRequestId() == "req" }},
+		{"loop", &d.Loop{RequestID: "req", Loop: 2}, func(f *pb.Frame) bool { return f.GetLoop().GetLoop() == 2 }},
+		{"response", &SubscriptionResponse{Code: 1, ID: "req"}, func(f *pb.Frame) bool { return f.GetResponse().GetId() == "req" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			frame, err := ProtoFrame(tt.data)
+			if err != nil {
+				t.Fatalf("ProtoFrame : %s", err)
+			}
+
+			if !tt.check(frame) {
+				t.Fatalf("ProtoFrame : unexpected frame %v", frame)
+			}
+
+		})
+	}
+
+	if _, err := ProtoFrame("order"); err == nil {
+		t.Fatal("ProtoFrame : expected error for unknown data")
+	}
+
+}
+
+func TestDeliver(t *testing.T) {
+
+	eof := &d.EOF{RequestID: "req"}
+
+	// Connections taking data as it is get it without being encoded
+	data := &values{}
+	if err := deliver(data, "protobuf", eof); err != nil {
+		t.Fatalf("deliver : %s", err)
+	}
+
+	if len(data.data) != 1 || data.data[0] != eof || len(data.messages.data) != 0 {
+		t.Fatalf("deliver : expected eof to be written as it is, got %v & %d messages", data.data, len(data.messages.data))
+	}
+
+	tests := []struct {
+		format      string
+		messageType int
+	}{
+		{"json", websocket.TextMessage},
+		{"protobuf", websocket.BinaryMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+
+			conn := &messages{}
+			if err := deliver(conn, tt.format, eof); err != nil {
+				t.Fatalf("deliver : %s", err)
+			}
+
+			if len(conn.types) != 1 || conn.types[0] != tt.messageType {
+				t.Fatalf("deliver : expected one message of type %d, got %v", tt.messageType, conn.types)
+			}
+
+			if tt.format == "protobuf" {
+				var frame pb.Frame
+				if err := proto.Unmarshal(conn.data[0], &frame); err != nil || frame.GetEof().GetRequestId() != "req" {
+					t.Fatalf("deliver : expected eof frame, got %v %v", &frame, err)
+				}
+			}
+
+		})
+	}
+
+}
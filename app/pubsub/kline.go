@@ -9,24 +9,24 @@ import (
 	"sync"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/gorilla/websocket"
+	"github.com/denniswon/tcex/app/broker"
+	d "github.com/denniswon/tcex/app/data"
 )
 
 // KlineConsumer - To be subscribed to `kline` topic using this consumer handle
 // and client connected using websocket needs to be delivered this piece of data
 type KlineConsumer struct {
-	Client     *redis.Client
+	Broker     broker.Broker
 	Request    *SubscriptionRequest
-	Connection *websocket.Conn
-	PubSub     *redis.PubSub
+	Connection Connection
+	PubSub     broker.Subscription
 	ConnLock   *sync.Mutex
 	TopicLock  *sync.RWMutex
 }
 
 // Subscribe - Subscribe to `kline` channel
 func (k *KlineConsumer) Subscribe() {
-	k.PubSub = k.Client.Subscribe(context.Background(), k.Request.ID)
+	k.PubSub = k.Broker.Subscribe(context.Background(), k.Request.ID)
 }
 
 // Listen - Listener function, which keeps looping in infinite loop
@@ -35,28 +35,28 @@ func (k *KlineConsumer) Listen() {
 
 	for {
 
-		msg, err := k.PubSub.ReceiveTimeout(context.Background(), time.Second)
+		msg, err := k.PubSub.Receive(context.Background(), time.Second)
 		if err != nil {
 			continue
 		}
 
-		switch m := msg.(type) {
+		switch msg.Kind {
 
-		case *redis.Subscription:
+		case "unsubscribe":
 
 			// Pubsub broker informed we've been unsubscribed from this topic
-			if m.Kind == "unsubscribe" {
-				return
-			}
+			return
+
+		case "subscribe":
 
 			k.SendData(&SubscriptionResponse{
 				Code:    1,
-				ID:      m.Channel,
-				Message: fmt.Sprintf("Subscribed to `%s`", m.Channel),
+				ID:      msg.Channel,
+				Message: fmt.Sprintf("Subscribed to `%s`", msg.Channel),
 			})
 
-		case *redis.Message:
-			k.Send(m.Payload)
+		case "message":
+			k.Send(msg.Payload)
 
 		}
 
@@ -67,22 +67,19 @@ func (k *KlineConsumer) Listen() {
 // connected over websocket
 func (k *KlineConsumer) Send(msg string) {
 
+	if strings.Contains(msg, `"loop"`) {
+		k.SendLoop(msg)
+		return
+
+	}
+
 	if strings.Contains(msg, "request_id") {
 		k.SendEOF(msg)
 		return
 
 	}
 
-	var kline struct {
-		Timestamp						uint64 	`json:"timestamp"` 		// bucket start time in unix timestamp
-		Low 								float32 `json:"low"`  			  // lowest price during the bucket interval
-		High 								float32 `json:"high"`  				// highest price during the bucket interval
-		Open								float32 `json:"open"`  				// opening price (first trade) in the bucket interval
-		Close 							float32 `json:"close"`  			// closing price (last trade) in the bucket interval
-		Volume 							int64 	`json:"volume"`  			// volume of trading activity during the bucket interval
-		Turnover						float64 `json:"turnover"`			// total usd volume of trading activity during the bucket interval
-		Granularity         uint16  `json:"granularity"`	// granularity field is in "seconds"
-	}
+	var kline d.Kline
 
 	_msg := []byte(msg)
 
@@ -101,9 +98,7 @@ func (k *KlineConsumer) Send(msg string) {
 // connected over websocket
 func (k *KlineConsumer) SendEOF(msg string) {
 
-	var eof struct{
-		RequestID	string `json:"request_id"`
-	}
+	var eof d.EOF
 
 	_msg := []byte(msg)
 
@@ -120,6 +115,27 @@ func (k *KlineConsumer) SendEOF(msg string) {
 	log.Printf("Published EOF for request %s\n", eof.RequestID)
 }
 
+// SendLoop - Tries to deliver replay loop marker to client application
+// connected over websocket
+func (k *KlineConsumer) SendLoop(msg string) {
+
+	var loop d.Loop
+
+	_msg := []byte(msg)
+
+	err := json.Unmarshal(_msg, &loop)
+
+	if err != nil {
+
+		log.Printf("[!] Failed to decode published loop marker data to JSON : %s\n", err.Error())
+
+		return
+	}
+
+	k.SendData(&loop)
+	log.Printf("Published loop marker %d for request %s\n", loop.Loop, loop.RequestID)
+}
+
 // SendData - Sending message to client application, connected over websocket
 //
 // If failed, we're going to remove subscription & close websocket
@@ -133,7 +149,7 @@ func (k *KlineConsumer) SendData(data interface{}) bool {
 	k.ConnLock.Lock()
 	defer k.ConnLock.Unlock()
 
-	if err := k.Connection.WriteJSON(data); err != nil {
+	if err := deliver(k.Connection, k.Request.Format, data); err != nil {
 		log.Printf("[!] Failed to deliver kline data for request %s : %s\n", k.Request.ID, err.Error())
 		return false
 	}
@@ -149,7 +165,7 @@ func (k *KlineConsumer) Unsubscribe() {
 		return
 	}
 
-	if err := k.PubSub.Unsubscribe(context.Background(), k.Request.ID); err != nil {
+	if err := k.PubSub.Unsubscribe(context.Background()); err != nil {
 		log.Printf("[!] Failed to unsubscribe from topic %s : %s\n", k.Request.ID, err.Error())
 		return
 	}
@@ -166,7 +182,7 @@ func (k *KlineConsumer) Unsubscribe() {
 	k.ConnLock.Lock()
 	defer k.ConnLock.Unlock()
 
-	if err := k.Connection.WriteJSON(resp); err != nil {
+	if err := deliver(k.Connection, k.Request.Format, resp); err != nil {
 
 		log.Printf("[!] Failed to deliver unsubscription confirmation for request %s : %s\n", k.Request.ID, err.Error())
 		return
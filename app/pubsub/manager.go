@@ -4,8 +4,7 @@ import (
 	"fmt"
 	"sync"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/gorilla/websocket"
+	"github.com/denniswon/tcex/app/broker"
 )
 
 // SubscriptionManager - Higher level abstraction to be used
@@ -13,16 +12,17 @@ import (
 type SubscriptionManager struct {
 	Topics     	map[string]*SubscriptionRequest
 	Consumers  	map[string]Consumer
-	Redis   	 	*redis.Client
-	Connection 	*websocket.Conn
+	Broker   	 	broker.Broker
+	Connection 	Connection
 	ConnLock   	*sync.Mutex
 	TopicLock  	*sync.RWMutex
 }
 
 // Subscribe - Websocket connection manager can reliably call
 // this function when ever it receives one valid subscription request
-// with out worrying about how will it be handled
-func (s *SubscriptionManager) Subscribe(req *SubscriptionRequest) {
+// with out worrying about how will it be handled, returns error
+// if there's no consumer for requested replay
+func (s *SubscriptionManager) Subscribe(req *SubscriptionRequest) error {
 
 	s.TopicLock.Lock()
 	defer s.TopicLock.Unlock()
@@ -30,16 +30,19 @@ func (s *SubscriptionManager) Subscribe(req *SubscriptionRequest) {
 	_, ok := s.Topics[req.ID]
 	if !ok {
 
-		s.Topics[req.ID] = req
-
 		switch req.Name {
 		case "order":
-			s.Consumers[req.ID] = NewOrderConsumer(s.Redis, req, s.Connection, s.ConnLock, s.TopicLock)
+			s.Consumers[req.ID] = NewOrderConsumer(s.Broker, req, s.Connection, s.ConnLock, s.TopicLock)
 
 		case "kline":
-			s.Consumers[req.ID] = NewKlineConsumer(s.Redis, req, s.Connection, s.ConnLock, s.TopicLock)
+			s.Consumers[req.ID] = NewKlineConsumer(s.Broker, req, s.Connection, s.ConnLock, s.TopicLock)
+
+		default:
+			return fmt.Errorf("unknown replay `%s`", req.Name)
 		}
 
+		s.Topics[req.ID] = req
+
 	}
 
 	s.Consumers[req.ID].SendData(
@@ -48,6 +51,8 @@ func (s *SubscriptionManager) Subscribe(req *SubscriptionRequest) {
 			Message: 	fmt.Sprintf("Subscription request for %s replay : `%s` (`x%f`)", req.Name, req.Filename, req.ReplayRate),
 			ID:    		req.ID,
 		})
+
+	return nil
 }
 
 // Unsubscribe - Websocket connection manager can reliably call
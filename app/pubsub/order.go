@@ -9,24 +9,24 @@ import (
 	"sync"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/gorilla/websocket"
+	"github.com/denniswon/tcex/app/broker"
+	d "github.com/denniswon/tcex/app/data"
 )
 
 // OrderConsumer - To be subscribed to `order` topic using this consumer handle
 // and client connected using websocket needs to be delivered this piece of data
 type OrderConsumer struct {
-	Client     *redis.Client
+	Broker     broker.Broker
 	Request    *SubscriptionRequest
-	Connection *websocket.Conn
-	PubSub     *redis.PubSub
+	Connection Connection
+	PubSub     broker.Subscription
 	ConnLock   *sync.Mutex
 	TopicLock  *sync.RWMutex
 }
 
 // Subscribe - Subscribe to `order` channel
 func (b *OrderConsumer) Subscribe() {
-	b.PubSub = b.Client.Subscribe(context.Background(), b.Request.ID)
+	b.PubSub = b.Broker.Subscribe(context.Background(), b.Request.ID)
 }
 
 // Listen - Listener function, which keeps looping in infinite loop
@@ -35,28 +35,28 @@ func (b *OrderConsumer) Listen() {
 
 	for {
 
-		msg, err := b.PubSub.ReceiveTimeout(context.Background(), time.Second)
+		msg, err := b.PubSub.Receive(context.Background(), time.Second)
 		if err != nil {
 			continue
 		}
 
-		switch m := msg.(type) {
+		switch msg.Kind {
 
-		case *redis.Subscription:
+		case "unsubscribe":
 
 			// Pubsub broker informed we've been unsubscribed from this topic
-			if m.Kind == "unsubscribe" {
-				return
-			}
+			return
+
+		case "subscribe":
 
 			b.SendData(&SubscriptionResponse{
 				Code:    1,
-				ID:      m.Channel,
-				Message: fmt.Sprintf("Subscribed to `%s`", m.Channel),
+				ID:      msg.Channel,
+				Message: fmt.Sprintf("Subscribed to `%s`", msg.Channel),
 			})
 
-		case *redis.Message:
-			b.Send(m.Payload)
+		case "message":
+			b.Send(msg.Payload)
 
 		}
 
@@ -67,18 +67,19 @@ func (b *OrderConsumer) Listen() {
 // connected over websocket
 func (b *OrderConsumer) Send(msg string) {
 
+	if strings.Contains(msg, `"loop"`) {
+		b.SendLoop(msg)
+		return
+
+	}
+
 	if strings.Contains(msg, "request_id") {
 		b.SendEOF(msg)
 		return
 
 	}
 
-	var order struct {
-		Price               string `json:"price"`
-		Quantity            uint64 `json:"quantity"`
-		Aggressor           string `json:"aggressor"`
-		Timestamp           int64  `json:"timestamp"`
-	}
+	var order d.Order
 
 	_msg := []byte(msg)
 
@@ -97,9 +98,7 @@ func (b *OrderConsumer) Send(msg string) {
 // connected over websocket
 func (b *OrderConsumer) SendEOF(msg string) {
 
-	var eof struct{
-		RequestID	string `json:"request_id"`
-	}
+	var eof d.EOF
 
 	_msg := []byte(msg)
 
@@ -116,6 +115,27 @@ func (b *OrderConsumer) SendEOF(msg string) {
 	log.Printf("Published EOF for request %s\n", eof.RequestID)
 }
 
+// SendLoop - Tries to deliver replay loop marker to client application
+// connected over websocket
+func (b *OrderConsumer) SendLoop(msg string) {
+
+	var loop d.Loop
+
+	_msg := []byte(msg)
+
+	err := json.Unmarshal(_msg, &loop)
+
+	if err != nil {
+
+		log.Printf("[!] Failed to decode published loop marker data to JSON : %s\n", err.Error())
+
+		return
+	}
+
+	b.SendData(&loop)
+	log.Printf("Published loop marker %d for request %s\n", loop.Loop, loop.RequestID)
+}
+
 // SendData - Sending message to client application, connected over websocket
 //
 // If failed, we're going to remove subscription & close websocket
@@ -129,7 +149,7 @@ func (b *OrderConsumer) SendData(data interface{}) bool {
 	b.ConnLock.Lock()
 	defer b.ConnLock.Unlock()
 
-	if err := b.Connection.WriteJSON(data); err != nil {
+	if err := deliver(b.Connection, b.Request.Format, data); err != nil {
 		log.Printf("[!] Failed to deliver order data for request %s : %s\n", b.Request.ID, err.Error())
 		return false
 	}
@@ -145,7 +165,7 @@ func (b *OrderConsumer) Unsubscribe() {
 		return
 	}
 
-	if err := b.PubSub.Unsubscribe(context.Background(), b.Request.ID); err != nil {
+	if err := b.PubSub.Unsubscribe(context.Background()); err != nil {
 		log.Printf("[!] Failed to unsubscribe from topic %s : %s\n", b.Request.ID, err.Error())
 		return
 	}
@@ -162,7 +182,7 @@ func (b *OrderConsumer) Unsubscribe() {
 	b.ConnLock.Lock()
 	defer b.ConnLock.Unlock()
 
-	if err := b.Connection.WriteJSON(resp); err != nil {
+	if err := deliver(b.Connection, b.Request.Format, resp); err != nil {
 
 		log.Printf("[!] Failed to deliver unsubscription confirmation for request %s : %s\n", b.Request.ID, err.Error())
 		return
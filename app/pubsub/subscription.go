@@ -1,18 +1,41 @@
 package pubsub
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"sort"
 
+	"github.com/denniswon/tcex/app/dataset"
+	"github.com/denniswon/tcex/app/source"
 	"github.com/google/uuid"
 )
 
 // UploadHeader
 type UploadHeader struct {
-	ID       string `json:"id"`
-	Filepath string `json:"filepath"`
-	Size     int64  `json:"size"` // size of the file if uploaded
+	ID        string `json:"id"` // id of uploaded dataset i.e. sha256 of stored file, or id of chunked upload being received
+	Filepath  string `json:"filepath"`
+	Size      int64  `json:"size"`                // size of the file if uploaded
+	SHA256    string `json:"sha256,omitempty"`    // hex encoded checksum of the file
+	Duplicate bool   `json:"duplicate,omitempty"` // whether same content had already been uploaded
+
+	Report *dataset.Report `json:"report,omitempty"` // outcome of validating uploaded file
+}
+
+// UploadStatus - Progress of chunked upload over websocket, sent to client
+// after each chunk & once upload is over
+type UploadStatus struct {
+	Type     string  `json:"type"` // "next", "retry", "done" or "error"
+	ID       string  `json:"id"`
+	Offset   int64   `json:"offset"` // # of bytes received so far, where next chunk is to start
+	Size     int64   `json:"size"`
+	Progress float64 `json:"progress"` // percentage of bytes received
+	Message  string  `json:"msg,omitempty"`
+
+	Header *UploadHeader   `json:"header,omitempty"` // uploaded dataset, once done
+	Report *dataset.Report `json:"report,omitempty"` // outcome of validating rejected file
 }
 
 func (header *UploadHeader) Generate() *UploadHeader {
@@ -34,14 +57,91 @@ func (header *UploadHeader) String() string {
 
 }
 
+// Loop - Number of times replay is to be played in a row, where
+// `true` stands for looping endlessly
+type Loop int64
+
+// LoopForever - Replay is to be started over, every time it's finished
+const LoopForever Loop = -1
+
+// UnmarshalJSON - Accepts either boolean or number of loops
+func (l *Loop) UnmarshalJSON(data []byte) error {
+
+	switch string(data) {
+
+	case "true":
+		*l = LoopForever
+
+	case "false", "null":
+		*l = 0
+
+	default:
+		var loops int64
+		if err := json.Unmarshal(data, &loops); err != nil {
+			return err
+		}
+
+		*l = Loop(loops)
+
+	}
+
+	return nil
+}
+
+// MaxGranularities - Max number of kline granularities, which can be
+// replayed in one subscription
+const MaxGranularities = 16
+
+// MaxAlignment - Max period ( in milliseconds ), after which buckets of all kline granularities
+// start together, for those to be looped, as each loop is shifted forward by its multiple
+const MaxAlignment int64 = 366 * 24 * 60 * 60 * 1000
+
+// Alignment - Least common multiple of given granularities ( in seconds ), in milliseconds,
+// so that buckets of all of them start at its multiples, returns false if it's over MaxAlignment
+func Alignment(granularities []uint16) (int64, bool) {
+	var size int64 = 1
+
+	for _, granularity := range granularities {
+		a, b := size, int64(granularity)
+		for b != 0 {
+			a, b = b, a%b
+		}
+
+		// Kept in seconds, it can't overflow, as long as it's within bound
+		size = size / a * int64(granularity)
+		if size*1000 > MaxAlignment {
+			return 0, false
+		}
+	}
+
+	return size * 1000, true
+}
+
 // SubscriptionRequest
 type SubscriptionRequest struct {
-	ID          string  `json:"id"`
-	Filename    string  `json:"filename"`
-	ReplayRate  float32 `json:"replay_rate"`
-	Type        string  `json:"type"`
-	Name        string  `json:"name"` // "order" or "kline"
-	Granularity uint16  `json:"granularity"`
+	ID            string   `json:"id" form:"id"`
+	Filename      string   `json:"filename" form:"filename"`
+	ReplayRate    float32  `json:"replay_rate" form:"replay_rate"`
+	Type          string   `json:"type" form:"-"`
+	Name          string   `json:"name" form:"name"`                   // "order" or "kline"
+	Granularity   uint16   `json:"granularity" form:"granularity"`     // optional, single kline granularity in seconds
+	Granularities []uint16 `json:"granularities" form:"granularities"` // optional, kline granularities in seconds, all replayed at once
+	StartTime     int64    `json:"start_time" form:"start_time"`       // optional, replay window start as original trade timestamp in milliseconds
+	EndTime       int64    `json:"end_time" form:"end_time"`           // optional, replay window end as original trade timestamp in milliseconds
+	WarmupFrom    int64    `json:"warmup_from" form:"warmup_from"`     // optional, original trade timestamp in milliseconds to build klines from
+	FillGaps      bool     `json:"fill_gaps" form:"fill_gaps"`         // optional, whether empty kline buckets are to be replayed too
+	BarType       string   `json:"bar_type" form:"bar_type"`           // optional, "time" ( default ), "tick", "volume" or "dollar" kline bars
+	Threshold     float64  `json:"threshold" form:"threshold"`         // # of trades, quantity or turnover closing each bar, other than time buckets
+	Loop          Loop     `json:"loop" form:"-"`                      // optional, `true` for endless looping or number of times to play replay
+	Timestamp     int64    `json:"timestamp" form:"-"`                 // original trade timestamp in milliseconds, used only for "seek" requests
+	Format        string   `json:"format" form:"-"`                    // optional, "json" ( default ) or "protobuf" frames to be delivered
+
+	FileFormat    string            `json:"file_format" form:"file_format"`       // optional, "jsonl", "csv" or "tsv" input file, detected if empty
+	TimestampUnit string            `json:"timestamp_unit" form:"timestamp_unit"` // optional, "s", "ms" ( default ), "us" or "ns" trade timestamps in input file
+	Columns       map[string]string `json:"columns" form:"-"`                     // optional, header name of each trade field in csv & tsv input file
+
+	Dataset string `json:"dataset" form:"dataset"` // optional, id of uploaded dataset to be replayed, instead of `filename`
+	Path    string `json:"-" form:"-"`             // input file, once it's resolved within data directories
 }
 
 func (req *SubscriptionRequest) Generate() *SubscriptionRequest {
@@ -54,23 +154,92 @@ func (req *SubscriptionRequest) Generate() *SubscriptionRequest {
 		req.ReplayRate = 60.0
 	}
 
-	if req.Name == "kline" && req.Granularity == 0 {
+	if req.Format == "" {
+		req.Format = "json"
+	}
+
+	if req.Name == "kline" && req.BarType == "" {
+		req.BarType = "time"
+	}
+
+	if req.BarType == "time" && req.Granularity == 0 && len(req.Granularities) == 0 {
 		req.Granularity = 60
 	}
 
+	// Single granularity is same as list of one
+	if req.Name == "kline" && req.Granularity != 0 {
+		req.Granularities = append(req.Granularities, req.Granularity)
+	}
+
+	// Each granularity is replayed once, from the finest one
+	sort.Slice(req.Granularities, func(i, j int) bool {
+		return req.Granularities[i] < req.Granularities[j]
+	})
+
+	granularities := req.Granularities[:0]
+	for i, granularity := range req.Granularities {
+		if i == 0 || granularity != req.Granularities[i-1] {
+			granularities = append(granularities, granularity)
+		}
+	}
+	req.Granularities = granularities
+
 	return req
 }
 
 func (req *SubscriptionRequest) Validate() bool {
-	ret := req.Filename != "" && req.ReplayRate > 0.0 && req.ID != "" && req.Name != ""
+	ret := req.Path != "" && req.ReplayRate > 0.0 && req.ID != "" && (req.Name == "order" || req.Name == "kline")
 	if req.Name == "kline" {
-		ret = ret && req.Granularity > 0
+		switch req.BarType {
+
+		case "time":
+			ret = ret && len(req.Granularities) > 0 && len(req.Granularities) <= MaxGranularities
+			for _, granularity := range req.Granularities {
+				ret = ret && granularity > 0
+			}
+
+			// Looped buckets must stay aligned to all granularities
+			if req.Loop == LoopForever || req.Loop > 1 {
+				_, ok := Alignment(req.Granularities)
+				ret = ret && ok
+			}
+
+		case "tick", "volume", "dollar":
+			// Bars are closed by trades, not by time
+			ret = ret && req.Threshold > 0 && len(req.Granularities) == 0 && !req.FillGaps
+			if req.BarType == "tick" {
+				ret = ret && req.Threshold == math.Trunc(req.Threshold)
+			}
+
+		default:
+			ret = false
+
+		}
+	} else {
+		ret = ret && req.BarType == ""
+	}
+
+	// Replay window must not be empty, and klines can only be
+	// warmed up with trades before the window
+	ret = ret && req.StartTime >= 0 && (req.EndTime == 0 || req.EndTime >= req.StartTime)
+	ret = ret && (req.WarmupFrom == 0 || (req.Name == "kline" && req.WarmupFrom <= req.StartTime))
+	ret = ret && (!req.FillGaps || req.Name == "kline")
+	ret = ret && req.Loop >= LoopForever
+	ret = ret && (req.Format == "json" || req.Format == "protobuf")
+
+	options := req.Source()
+	if err := options.Check(); err != nil {
+
+		log.Printf("Bad input file options : %s\n", err.Error())
+
+		ret = false
+
 	}
 
 	// Check if file exists
-	if _, err := os.Stat(req.Filename); err != nil {
+	if _, err := os.Stat(req.Path); err != nil {
 
-		log.Printf("Request input file does not exist : %s\n", req.Filename)
+		log.Printf("Request input file does not exist : %s\n", req.Path)
 
 		ret = false
 
@@ -79,22 +248,84 @@ func (req *SubscriptionRequest) Validate() bool {
 	return ret
 }
 
+// Resolve - Finds input file of request, which is either uploaded dataset with given id, whose
+// decoding options are used unless they're given, or file within data directories
+func (req *SubscriptionRequest) Resolve(resolver *dataset.Resolver) bool {
+
+	if req.Dataset != "" {
+
+		_dataset, err := resolver.Dataset(req.Dataset)
+		if err != nil {
+			log.Printf("Request dataset does not exist : %s\n", req.Dataset)
+			return false
+		}
+
+		if req.Filename == "" {
+			req.Filename = _dataset.Filename
+		}
+
+		if req.FileFormat == "" {
+			req.FileFormat = _dataset.FileFormat
+		}
+
+		if req.TimestampUnit == "" {
+			req.TimestampUnit = _dataset.TimestampUnit
+		}
+
+		if req.Columns == nil {
+			req.Columns = _dataset.Columns
+		}
+
+		req.Path = _dataset.Filepath
+		return true
+
+	}
+
+	path, err := resolver.Resolve(req.Filename)
+	if err != nil {
+		log.Printf("Failed to resolve request input file %s : %s\n", req.Filename, err.Error())
+		return false
+	}
+
+	req.Path = path
+	return true
+}
+
+// Source - How input file is to be decoded
+func (req *SubscriptionRequest) Source() source.Options {
+	return source.Options{
+		Format:  req.FileFormat,
+		Unit:    req.TimestampUnit,
+		Columns: req.Columns,
+	}
+}
+
 func (req *SubscriptionRequest) String() string {
 	if req.Name == "kline" {
-		return fmt.Sprintf(`{"request_id":%s,"filename":%s,"replay_rate":%f,"name":%s,"granularity":%d}`,
+		return fmt.Sprintf(`{"request_id":%s,"filename":%s,"replay_rate":%f,"name":%s,"bar_type":%s,"granularities":%v,"threshold":%f,"start_time":%d,"end_time":%d,"warmup_from":%d,"fill_gaps":%t,"format":%s}`,
 			req.ID,
 			req.Filename,
 			req.ReplayRate,
 			req.Name,
-			req.Granularity,
+			req.BarType,
+			req.Granularities,
+			req.Threshold,
+			req.StartTime,
+			req.EndTime,
+			req.WarmupFrom,
+			req.FillGaps,
+			req.Format,
 		)
 	}
 
-	return fmt.Sprintf(`{"request_id":%s,"filename":%s,"replay_rate":%f,"name":%s}`,
+	return fmt.Sprintf(`{"request_id":%s,"filename":%s,"replay_rate":%f,"name":%s,"start_time":%d,"end_time":%d,"format":%s}`,
 		req.ID,
 		req.Filename,
 		req.ReplayRate,
 		req.Name,
+		req.StartTime,
+		req.EndTime,
+		req.Format,
 	)
 }
 
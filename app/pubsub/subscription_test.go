@@ -0,0 +1,108 @@
+package pubsub
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAlignment(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		granularities []uint16
+		expected      int64
+		ok            bool
+	}{
+		{"none", []uint16{}, 1_000, true},
+		{"single", []uint16{60}, 60_000, true},
+		{"multiples", []uint16{60, 300, 3600}, 3_600_000, true},
+		{"coprime", []uint16{7, 60}, 420_000, true},
+		{"over bound", []uint16{65521, 65519, 65497}, 0, false},
+		{"many coprimes", []uint16{7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53, 59, 61, 67}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			size, ok := Alignment(tt.granularities)
+			if size != tt.expected || ok != tt.ok {
+				t.Fatalf("Alignment(%v) : expected %d %t, got %d %t", tt.granularities, tt.expected, tt.ok, size, ok)
+			}
+
+		})
+	}
+
+}
+
+func TestValidateLoopAlignment(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "trades.jsonl")
+	if err := os.WriteFile(path, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	overflowing := []uint16{65521, 65519, 65497}
+
+	tests := []struct {
+		name          string
+		granularities []uint16
+		loop          Loop
+		valid         bool
+	}{
+		{"played once", overflowing, 0, true},
+		{"played twice", overflowing, 2, false},
+		{"looped forever", overflowing, LoopForever, false},
+		{"aligned within bound", []uint16{60, 300, 3600}, LoopForever, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			req := (&SubscriptionRequest{
+				ID:            "req",
+				Name:          "kline",
+				Path:          path,
+				Granularities: tt.granularities,
+				Loop:          tt.loop,
+			}).Generate()
+
+			if valid := req.Validate(); valid != tt.valid {
+				t.Fatalf("Validate : expected %t, got %t", tt.valid, valid)
+			}
+
+		})
+	}
+
+}
+
+func TestValidateName(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "trades.jsonl")
+	if err := os.WriteFile(path, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"order", true},
+		{"kline", true},
+		{"trade", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			req := (&SubscriptionRequest{ID: "req", Name: tt.name, Path: path}).Generate()
+
+			if valid := req.Validate(); valid != tt.valid {
+				t.Fatalf("Validate : expected %t for `%s`, got %t", tt.valid, tt.name, valid)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,155 @@
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/denniswon/tcex/app/source"
+)
+
+// seekTimestamp - Finds offset of the first line in input file, with trade timestamp
+// at or after given one ( in milliseconds ). Trades in input file are expected to be
+// sorted by timestamp, so it's binary searched, instead of reading whole file
+//
+// If there's no such line, size of the file is returned. Compressed files can't be
+// seeked, so those are to be read from their first trade, skipping earlier ones
+func seekTimestamp(file *source.File, decoder *source.Decoder, timestamp int64) (int64, error) {
+
+	if file.Compressed() {
+		return decoder.Start, nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	lo, hi := decoder.Start, info.Size()
+
+	for lo < hi {
+
+		mid := lo + (hi-lo)/2
+
+		_, ts, ok, err := lineAt(file.File, decoder, mid, info.Size())
+		if err != nil {
+			return 0, err
+		}
+
+		if !ok || ts >= timestamp {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+
+	}
+
+	offset, _, ok, err := lineAt(file.File, decoder, lo, info.Size())
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return info.Size(), nil
+	}
+
+	return offset, nil
+}
+
+// firstTimestamp - Finds timestamp of the first trade in input file,
+// at or after given timestamp ( in milliseconds )
+func firstTimestamp(file *source.File, decoder *source.Decoder, timestamp int64) (int64, bool, error) {
+
+	offset, err := seekTimestamp(file, decoder, timestamp)
+	if err != nil {
+		return 0, false, err
+	}
+
+	reader, err := file.Section(offset)
+	if err != nil {
+		return 0, false, err
+	}
+	defer reader.Close()
+
+	// Trades before given timestamp are there only if file couldn't be seeked
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		order, err := decoder.Decode(scanner.Bytes())
+		if err != nil {
+			return 0, false, err
+		}
+
+		if order.Timestamp >= timestamp {
+			return order.Timestamp, true, nil
+		}
+
+	}
+
+	return 0, false, scanner.Err()
+}
+
+// lineAt - Reads first non-empty line starting at or after given offset, returning its
+// offset & trade timestamp, or false if there's no such line in input file
+//
+// Header line is never read, so offsets before the first trade line are moved to it
+func lineAt(file *os.File, decoder *source.Decoder, offset int64, size int64) (int64, int64, bool, error) {
+
+	if offset < decoder.Start {
+		offset = decoder.Start
+	}
+
+	start := offset
+
+	// Unless given offset is right at the beginning of a line,
+	// rest of the line it falls in is to be skipped
+	if offset > 0 {
+		prev := make([]byte, 1)
+		if _, err := file.ReadAt(prev, offset-1); err != nil {
+			return 0, 0, false, err
+		}
+
+		if prev[0] != '\n' {
+			start--
+		}
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(file, start, size-start))
+	skip := start < offset
+
+	for {
+
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return 0, 0, false, err
+		}
+
+		if len(line) == 0 {
+			return 0, 0, false, nil
+		}
+
+		if skip || len(bytes.TrimSpace(line)) == 0 {
+			skip = false
+			start += int64(len(line))
+
+			if err == io.EOF {
+				return 0, 0, false, nil
+			}
+
+			continue
+		}
+
+		order, err := decoder.Decode(line)
+		if err != nil {
+			return 0, 0, false, err
+		}
+
+		return start, order.Timestamp, true, nil
+
+	}
+}
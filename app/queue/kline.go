@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"math"
+	"strconv"
+
+	d "github.com/denniswon/tcex/app/data"
+)
+
+// bucketStart - Start of kline bucket given trade timestamp falls in, aligned
+// to multiples of granularity ( in seconds ), in milliseconds
+func bucketStart(timestamp int64, granularity uint16) int64 {
+	size := int64(granularity) * 1000
+	if size == 0 {
+		return timestamp
+	}
+
+	return timestamp - timestamp%size
+}
+
+// bucketsStart - Earliest start of kline buckets of given granularities,
+// given trade timestamp falls in, in milliseconds
+func bucketsStart(timestamp int64, granularities []uint16) int64 {
+	start := timestamp
+
+	for _, granularity := range granularities {
+		if _start := bucketStart(timestamp, granularity); _start < start {
+			start = _start
+		}
+	}
+
+	return start
+}
+
+// bucketEnd - End of given kline bucket ( exclusive ), when next bucket begins
+func bucketEnd(kline *d.Kline) int64 {
+	return kline.Timestamp + int64(kline.Granularity)*1000
+}
+
+// newKline - Opens new kline bucket with given trade, which falls in it
+func newKline(order *d.Order, granularity uint16) d.Kline {
+	price := tradePrice(order)
+
+	kline := d.Kline{
+		Timestamp:   bucketStart(order.Timestamp, granularity),
+		Open:        price,
+		Low:         price,
+		High:        price,
+		Granularity: granularity,
+		BarType:     "time",
+	}
+
+	addTrade(&kline, order)
+	return kline
+}
+
+// emptyKline - Bucket starting at given timestamp, which no trade falls in,
+// so that its prices stay at given close price of previous bucket
+func emptyKline(close float64, timestamp int64, granularity uint16) d.Kline {
+	return d.Kline{
+		Timestamp:   timestamp,
+		Low:         close,
+		High:        close,
+		Open:        close,
+		Close:       close,
+		Granularity: granularity,
+		BarType:     "time",
+	}
+}
+
+// newBar - Opens new bar of given type, which is closed once it reaches given
+// threshold, with given trade i.e. it starts at timestamp of this trade
+func newBar(order *d.Order, barType string, threshold float64) d.Kline {
+	bar := newKline(order, 0)
+	bar.BarType = barType
+	bar.Threshold = threshold
+
+	return bar
+}
+
+// barFull - Whether given bar has reached its threshold, measured
+// in number of trades, quantity or turnover, as per its type
+func barFull(bar *d.Kline) bool {
+	switch bar.BarType {
+	case "tick":
+		return float64(bar.Trades) >= bar.Threshold
+	case "volume":
+		return float64(bar.GrossVolume) >= bar.Threshold
+	case "dollar":
+		return bar.Turnover >= bar.Threshold
+	}
+
+	return false
+}
+
+// addTrade - Updates kline bucket with given trade, which falls in it
+func addTrade(kline *d.Kline, order *d.Order) {
+	price := tradePrice(order)
+
+	kline.Low = math.Min(kline.Low, price)
+	kline.High = math.Max(kline.High, price)
+	kline.Close = price
+
+	if isSell(order) {
+		kline.Volume -= int64(order.Quantity)
+		kline.SellVolume += order.Quantity
+	} else {
+		kline.Volume += int64(order.Quantity)
+		kline.BuyVolume += order.Quantity
+	}
+
+	kline.GrossVolume += order.Quantity
+	kline.Trades++
+	kline.Turnover += price * float64(order.Quantity)
+	if kline.GrossVolume > 0 {
+		kline.VWAP = kline.Turnover / float64(kline.GrossVolume)
+	}
+}
+
+// tradePrice - Price of given trade as number
+func tradePrice(order *d.Order) float64 {
+	price, _ := strconv.ParseFloat(order.Price, 64)
+	return price
+}
+
+// isSell - Whether aggressor of given trade is seller i.e. hitting the bid,
+// otherwise it's buyer lifting the ask
+func isSell(order *d.Order) bool {
+	return order.Aggressor == "ask"
+}
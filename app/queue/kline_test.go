@@ -0,0 +1,463 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	d "github.com/denniswon/tcex/app/data"
+	ps "github.com/denniswon/tcex/app/pubsub"
+)
+
+// klineTest - Kline subscription replaying given trades, along with klines expected to be replayed
+type klineTest struct {
+	name     string
+	trades   []trade
+	request  ps.SubscriptionRequest
+	expected []string
+}
+
+// runKlines - Replays each kline subscription from its start, comparing replayed klines
+func runKlines(t *testing.T, tests []klineTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			tq := newTestQueue(t)
+
+			tt.request.Name = "kline"
+			request := newRequest(writeTrades(t, tt.trades), tt.request)
+			if !request.Validate() {
+				t.Fatalf("invalid request : %+v", request)
+			}
+			tq.open(t, request)
+
+			var got []string
+			for _, order := range tq.run(t, request, request.StartTime) {
+				got = append(got, order.kline(t))
+			}
+
+			expect(t, got, tt.expected)
+
+		})
+	}
+}
+
+func TestBucketStart(t *testing.T) {
+
+	tests := []struct {
+		timestamp   int64
+		granularity uint16
+		expected    int64
+	}{
+		{0, 60, 0},
+		{59_999, 60, 0},
+		{60_000, 60, 60_000},
+		{60_001, 60, 60_000},
+		{1_700_000_123_456, 60, 1_700_000_100_000},
+		{1_700_000_123_456, 3600, 1_699_999_200_000},
+		{1_700_000_123_456, 0, 1_700_000_123_456},
+	}
+
+	for _, tt := range tests {
+		if got := bucketStart(tt.timestamp, tt.granularity); got != tt.expected {
+			t.Errorf("bucketStart(%d, %d) : expected %d, got %d", tt.timestamp, tt.granularity, tt.expected, got)
+		}
+	}
+
+}
+
+func TestRunKlineBoundaries(t *testing.T) {
+
+	trades := []trade{
+		{timestamp: 1_000, price: "1"},
+		{timestamp: 59_999, price: "2"},
+		{timestamp: 60_000, price: "3"},
+		{timestamp: 61_000, price: "1.5"},
+		{timestamp: 130_000, price: "4"},
+	}
+
+	runKlines(t, []klineTest{
+		{
+			// Buckets are aligned to granularity, not to the first trade, and
+			// bucket is closed right when next one begins
+			name:    "aligned buckets",
+			trades:  trades,
+			request: ps.SubscriptionRequest{Granularity: 60},
+			expected: []string{
+				"0 1/1/1/1 g60 @1000",
+				"0 1/2/1/2 g60 @59999",
+				"0 1/2/1/2 closed g60 @60000",
+				"60000 3/3/3/3 g60 @60000",
+				"60000 3/3/1.5/1.5 g60 @61000",
+				"60000 3/3/1.5/1.5 closed g60 @120000",
+				"120000 4/4/4/4 g60 @130000",
+				"eof @130000",
+			},
+		},
+		{
+			// Last bucket is closed at the end of replay window, only if it's over by then
+			name:    "window ending with bucket",
+			trades:  trades,
+			request: ps.SubscriptionRequest{Granularity: 60, EndTime: 120_000},
+			expected: []string{
+				"0 1/1/1/1 g60 @1000",
+				"0 1/2/1/2 g60 @59999",
+				"0 1/2/1/2 closed g60 @60000",
+				"60000 3/3/3/3 g60 @60000",
+				"60000 3/3/1.5/1.5 g60 @61000",
+				"60000 3/3/1.5/1.5 closed g60 @120000",
+				"eof @120000",
+			},
+		},
+		{
+			name:    "window ending mid bucket",
+			trades:  trades,
+			request: ps.SubscriptionRequest{Granularity: 60, EndTime: 90_000},
+			expected: []string{
+				"0 1/1/1/1 g60 @1000",
+				"0 1/2/1/2 g60 @59999",
+				"0 1/2/1/2 closed g60 @60000",
+				"60000 3/3/3/3 g60 @60000",
+				"60000 3/3/1.5/1.5 g60 @61000",
+				"eof @90000",
+			},
+		},
+		{
+			// Klines are built only from replay window start, unless they're warmed up
+			name:    "window starting mid bucket",
+			trades:  trades,
+			request: ps.SubscriptionRequest{Granularity: 60, StartTime: 59_999},
+			expected: []string{
+				"0 2/2/2/2 g60 @59999",
+				"0 2/2/2/2 closed g60 @60000",
+				"60000 3/3/3/3 g60 @60000",
+				"60000 3/3/1.5/1.5 g60 @61000",
+				"60000 3/3/1.5/1.5 closed g60 @120000",
+				"120000 4/4/4/4 g60 @130000",
+				"eof @130000",
+			},
+		},
+		{
+			// Warmup trades make up bucket, though they aren't replayed on their own
+			name:    "warmed up window",
+			trades:  trades,
+			request: ps.SubscriptionRequest{Granularity: 60, StartTime: 59_999, WarmupFrom: 1_000},
+			expected: []string{
+				"0 1/2/1/2 g60 @59999",
+				"0 1/2/1/2 closed g60 @60000",
+				"60000 3/3/3/3 g60 @60000",
+				"60000 3/3/1.5/1.5 g60 @61000",
+				"60000 3/3/1.5/1.5 closed g60 @120000",
+				"120000 4/4/4/4 g60 @130000",
+				"eof @130000",
+			},
+		},
+	})
+
+}
+
+func TestRunKlineGaps(t *testing.T) {
+
+	trades := []trade{
+		{timestamp: 1_000, price: "1"},
+		{timestamp: 185_000, price: "2"},
+	}
+
+	runKlines(t, []klineTest{
+		{
+			name:    "gaps skipped",
+			trades:  trades,
+			request: ps.SubscriptionRequest{Granularity: 60},
+			expected: []string{
+				"0 1/1/1/1 g60 @1000",
+				"0 1/1/1/1 closed g60 @60000",
+				"180000 2/2/2/2 g60 @185000",
+				"eof @185000",
+			},
+		},
+		{
+			// Empty bucket is replayed right when it begins, carrying previous close price
+			name:    "gaps filled",
+			trades:  trades,
+			request: ps.SubscriptionRequest{Granularity: 60, FillGaps: true},
+			expected: []string{
+				"0 1/1/1/1 g60 @1000",
+				"0 1/1/1/1 closed g60 @60000",
+				"60000 1/1/1/1 g60 @60000",
+				"60000 1/1/1/1 closed g60 @120000",
+				"120000 1/1/1/1 g60 @120000",
+				"120000 1/1/1/1 closed g60 @180000",
+				"180000 2/2/2/2 g60 @185000",
+				"eof @185000",
+			},
+		},
+		{
+			// Bucket which begins with trade isn't replayed empty first
+			name:    "trade on boundary",
+			trades:  []trade{{timestamp: 1_000, price: "1"}, {timestamp: 120_000, price: "2"}},
+			request: ps.SubscriptionRequest{Granularity: 60, FillGaps: true},
+			expected: []string{
+				"0 1/1/1/1 g60 @1000",
+				"0 1/1/1/1 closed g60 @60000",
+				"60000 1/1/1/1 g60 @60000",
+				"60000 1/1/1/1 closed g60 @120000",
+				"120000 2/2/2/2 g60 @120000",
+				"eof @120000",
+			},
+		},
+		{
+			// Gaps till the end of replay window are filled too
+			name:    "gap till window end",
+			trades:  trades[:1],
+			request: ps.SubscriptionRequest{Granularity: 60, FillGaps: true, EndTime: 150_000},
+			expected: []string{
+				"0 1/1/1/1 g60 @1000",
+				"0 1/1/1/1 closed g60 @60000",
+				"60000 1/1/1/1 g60 @60000",
+				"60000 1/1/1/1 closed g60 @120000",
+				"120000 1/1/1/1 g60 @120000",
+				"eof @150000",
+			},
+		},
+	})
+
+}
+
+func TestRunKlineGranularities(t *testing.T) {
+
+	trades := []trade{
+		{timestamp: 1_000, price: "1"},
+		{timestamp: 61_000, price: "2"},
+		{timestamp: 121_000, price: "3"},
+	}
+
+	runKlines(t, []klineTest{
+		{
+			// Buckets of all granularities are built from same trades, each one
+			// replayed on its own, from the finest granularity
+			name:    "granularities",
+			trades:  trades,
+			request: ps.SubscriptionRequest{Granularities: []uint16{120, 60}},
+			expected: []string{
+				"0 1/1/1/1 g60 @1000",
+				"0 1/1/1/1 g120 @1000",
+				"0 1/1/1/1 closed g60 @60000",
+				"60000 2/2/2/2 g60 @61000",
+				"0 1/2/1/2 g120 @61000",
+				"60000 2/2/2/2 closed g60 @120000",
+				"0 1/2/1/2 closed g120 @120000",
+				"120000 3/3/3/3 g60 @121000",
+				"120000 3/3/3/3 g120 @121000",
+				"eof @121000",
+			},
+		},
+		{
+			name:    "duplicate granularity",
+			trades:  trades[:1],
+			request: ps.SubscriptionRequest{Granularity: 60, Granularities: []uint16{60}},
+			expected: []string{
+				"0 1/1/1/1 g60 @1000",
+				"eof @1000",
+			},
+		},
+	})
+
+}
+
+func TestRunKlineLoopSpan(t *testing.T) {
+
+	path := writeTrades(t, []trade{
+		{timestamp: 1_000, price: "1"},
+		{timestamp: 121_000, price: "2"},
+	})
+
+	tests := []struct {
+		name          string
+		granularities []uint16
+		span          int64
+	}{
+		// Loop spans 120_001ms, which is rounded up to multiple of all granularities
+		{"multiples", []uint16{60, 120}, 240_000},
+		{"coprime", []uint16{60, 90}, 180_000},
+		{"single", []uint16{7}, 126_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			tq := newTestQueue(t)
+			request := newRequest(path, ps.SubscriptionRequest{Name: "kline", Granularities: tt.granularities, Loop: 2})
+			if !request.Validate() {
+				t.Fatalf("invalid request : %+v", request)
+			}
+			cursor := tq.open(t, request)
+
+			orders := tq.run(t, request, 0)
+			if last := orders[len(orders)-1]; last.EOF || !strings.Contains(last.data, `"loop"`) {
+				t.Fatalf("expected replay to end with loop marker, got %+v", last)
+			}
+
+			if cursor.Span != tt.span {
+				t.Fatalf("expected loop span %d, got %d", tt.span, cursor.Span)
+			}
+
+		})
+	}
+
+}
+
+// volumes - Volumes of replayed kline, formatted as `buy/sell net gross trades turnover vwap`
+func (r replayed) volumes(t *testing.T) string {
+	t.Helper()
+
+	if r.EOF {
+		return "eof"
+	}
+
+	var kline d.Kline
+	if err := json.Unmarshal([]byte(r.data), &kline); err != nil {
+		t.Fatalf("order %d : bad kline %q : %s", r.OrderNumber, r.data, err)
+	}
+
+	return fmt.Sprintf("%d/%d %d %d %d %g %.4f",
+		kline.BuyVolume, kline.SellVolume, kline.Volume, kline.GrossVolume, kline.Trades, kline.Turnover, kline.VWAP)
+}
+
+func TestRunKlineVolumes(t *testing.T) {
+
+	path := writeTrades(t, []trade{
+		{timestamp: 1_000, price: "1", quantity: 2, aggressor: "bid"},
+		{timestamp: 2_000, price: "3", quantity: 1, aggressor: "ask"},
+		{timestamp: 3_000, price: "2", quantity: 4, aggressor: "ask"},
+		{timestamp: 121_000, price: "5", quantity: 1, aggressor: "bid"},
+	})
+
+	tq := newTestQueue(t)
+	request := newRequest(path, ps.SubscriptionRequest{Name: "kline", Granularity: 60, FillGaps: true})
+	tq.open(t, request)
+
+	var got []string
+	for _, order := range tq.run(t, request, 0) {
+		got = append(got, order.volumes(t))
+	}
+
+	// Buyer lifting the ask adds to net volume, while seller hitting the bid takes from it,
+	// and empty bucket carries no volume, though it carries previous close price
+	expect(t, got, []string{
+		"2/0 2 2 1 2 1.0000",
+		"2/1 1 3 2 5 1.6667",
+		"2/5 -3 7 3 13 1.8571",
+		"2/5 -3 7 3 13 1.8571",
+		"0/0 0 0 0 0 0.0000",
+		"0/0 0 0 0 0 0.0000",
+		"1/0 1 1 1 5 5.0000",
+		"eof",
+	})
+
+}
+
+func TestRunKlineBars(t *testing.T) {
+
+	runKlines(t, []klineTest{
+		{
+			// Bar starts at its first trade & is closed with the trade making it reach threshold
+			name: "tick",
+			trades: []trade{
+				{timestamp: 0, price: "1"},
+				{timestamp: 1_000, price: "2"},
+				{timestamp: 2_000, price: "3"},
+				{timestamp: 3_000, price: "4"},
+				{timestamp: 4_000, price: "5"},
+			},
+			request: ps.SubscriptionRequest{BarType: "tick", Threshold: 2},
+			expected: []string{
+				"0 1/1/1/1 g0 @0",
+				"0 1/2/1/2 closed g0 @1000",
+				"2000 3/3/3/3 g0 @2000",
+				"2000 3/4/3/4 closed g0 @3000",
+				"4000 5/5/5/5 g0 @4000",
+				"eof @4000",
+			},
+		},
+		{
+			// Trade going over threshold isn't split across bars
+			name: "volume",
+			trades: []trade{
+				{timestamp: 1_000, price: "1", quantity: 1},
+				{timestamp: 2_000, price: "2", quantity: 2},
+				{timestamp: 3_000, price: "3", quantity: 5},
+				{timestamp: 4_000, price: "4", quantity: 1},
+			},
+			request: ps.SubscriptionRequest{BarType: "volume", Threshold: 3},
+			expected: []string{
+				"1000 1/1/1/1 g0 @1000",
+				"1000 1/2/1/2 closed g0 @2000",
+				"3000 3/3/3/3 closed g0 @3000",
+				"4000 4/4/4/4 g0 @4000",
+				"eof @4000",
+			},
+		},
+		{
+			name: "dollar",
+			trades: []trade{
+				{timestamp: 1_000, price: "2", quantity: 2},
+				{timestamp: 2_000, price: "3", quantity: 2},
+				{timestamp: 3_000, price: "10", quantity: 1},
+				{timestamp: 4_000, price: "1", quantity: 1},
+			},
+			request: ps.SubscriptionRequest{BarType: "dollar", Threshold: 10},
+			expected: []string{
+				"1000 2/2/2/2 g0 @1000",
+				"1000 2/3/2/3 closed g0 @2000",
+				"3000 10/10/10/10 closed g0 @3000",
+				"4000 1/1/1/1 g0 @4000",
+				"eof @4000",
+			},
+		},
+		{
+			// Bars aren't closed by time, neither at the end of replay window
+			name: "window end",
+			trades: []trade{
+				{timestamp: 1_000, price: "1"},
+				{timestamp: 200_000, price: "2"},
+			},
+			request: ps.SubscriptionRequest{BarType: "tick", Threshold: 3, EndTime: 300_000},
+			expected: []string{
+				"1000 1/1/1/1 g0 @1000",
+				"1000 1/2/1/2 g0 @200000",
+				"eof @300000",
+			},
+		},
+	})
+
+}
+
+func TestRunKlineBarsSeek(t *testing.T) {
+
+	path := writeTrades(t, []trade{
+		{timestamp: 1_000, price: "1"},
+		{timestamp: 2_000, price: "2"},
+		{timestamp: 3_000, price: "3"},
+		{timestamp: 4_000, price: "4"},
+	})
+
+	tq := newTestQueue(t)
+	request := newRequest(path, ps.SubscriptionRequest{Name: "kline", BarType: "tick", Threshold: 3})
+	tq.open(t, request)
+
+	// Where bar starts depends on all trades before, so those are read again
+	var got []string
+	for _, order := range tq.run(t, request, 2_500) {
+		got = append(got, order.kline(t))
+	}
+
+	expect(t, got, []string{
+		"1000 1/3/1/3 closed g0 @3000",
+		"4000 4/4/4/4 g0 @4000",
+		"eof @4000",
+	})
+
+}
@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"container/heap"
 	"log"
 	"sync"
 	"time"
@@ -13,7 +14,8 @@ import (
 type Status struct {
 	Order             		Order
 	Inserted            	bool // 1. Order data inserted to queue
-	Published           	bool // 2. Pub/Sub publishing
+	Dispatched          	bool // 2. Handed over to publisher, once due
+	Published           	bool // 3. Pub/Sub publishing
 }
 
 type PutRequest struct {
@@ -29,6 +31,25 @@ type Request struct {
 }
 
 
+// Control - Session level control request ( i.e. open/ pause/ resume/ cancel/ close ),
+// client receives confirmation over channel that they specify
+type Control struct {
+	RequestId			string
+	Action				string
+	Rate					float32
+	OrderNumber		uint64
+	ResponseChan 	chan bool
+}
+
+// Admission - Order read ahead of time, waiting to fit into look-ahead window of
+// its replay session, before being put into queue. Client receives false, when
+// it's not supposed to be put anymore
+type Admission struct {
+	RequestId			string
+	Timestamp			int64
+	ResponseChan 	chan bool
+}
+
 // Next - Order to be processed next, asked by sending this request
 type Next struct {
 	ResponseChan chan struct {
@@ -42,27 +63,41 @@ type Next struct {
 // ReplayQueue - concurrent safe queue to be interacted with before attempting to replay any order
 type ReplayQueue struct {
 	Orders                map[string]*Status
+	schedule              Schedule
+	sessions              map[string]*Session
 	PutChan               chan PutRequest
 	CanPublishChan        chan Request
 	PublishedChan         chan Request
 	PublishNextChan    		chan Next
+	AdmitChan             chan Admission
+	ControlChan           chan Control
 	CleanUpChan           chan bool
 	stopChannel 					chan string
 	mutex 								*sync.RWMutex
 	stopped               bool
+	window                uint64 // max # of pending orders per session, 0 means no limit
+	horizon               int64  // max look-ahead in original trade time ( in microseconds ), 0 means no limit
 }
 
 // New - Getting new instance of queue, to be invoked during setting up application
-func NewReplayQueue() *ReplayQueue {
+//
+// Each session can have at most `window` orders pending, which are not more than `horizon`
+// seconds ( of original trade time ) ahead of its current replay position
+func NewReplayQueue(window uint64, horizon uint64) *ReplayQueue {
 
 	return &ReplayQueue{
 		Orders:                make(map[string]*Status),
+		sessions:              make(map[string]*Session),
 		PutChan:               make(chan PutRequest, 128),
 		CanPublishChan:        make(chan Request, 128),
 		PublishedChan:         make(chan Request, 128),
 		PublishNextChan:     	 make(chan Next, 1),
+		AdmitChan:             make(chan Admission, 128),
+		ControlChan:           make(chan Control, 128),
 		stopChannel:           make(chan string, 1),
 		mutex:                 &sync.RWMutex{},
+		window:                window,
+		horizon:               int64(horizon) * 1_000_000,
 	}
 
 }
@@ -114,9 +149,15 @@ func (q *ReplayQueue) Published(order string) bool {
 
 }
 
-// PublishNext - Next order that can be published
+// PublishNext - Next order that can be published, blocks until it's due
+//
+// Returns false only when queue has been stopped
 func (q *ReplayQueue) PublishNext() (string, int64, bool, bool) {
 
+	if q.IsStopped() {
+		return "", 0, false, false
+	}
+
 	resp := make(chan struct {
 		Status bool
 		Order  string
@@ -132,6 +173,105 @@ func (q *ReplayQueue) PublishNext() (string, int64, bool, bool) {
 
 }
 
+// Admit - Blocks until order with given original trade timestamp ( in microseconds ) fits
+// into look-ahead window of given replay session, so that reading input file is throttled
+// by replay progress
+//
+// Returns false if session is gone or cancelled, queue has been stopped or waiting
+// got interrupted over given channel, in which case admission is not waited for anymore
+func (q *ReplayQueue) Admit(requestId string, timestamp int64, interrupt <-chan struct{}) bool {
+
+	if q.IsStopped() {
+		return false
+	}
+
+	// Queue never blocks on responding, even if client's not waiting anymore
+	resp := make(chan bool, 1)
+	req := Admission{
+		RequestId:    requestId,
+		Timestamp:    timestamp,
+		ResponseChan: resp,
+	}
+
+	q.AdmitChan <- req
+
+	select {
+	case ok := <-resp:
+		return ok
+	case <-interrupt:
+		return false
+	}
+
+}
+
+// Open - Registers new replay session for given request, whose clock
+// runs at given replay rate
+func (q *ReplayQueue) Open(requestId string, rate float32) bool {
+	return q.control(requestId, "open", rate)
+}
+
+// Pause - Freezes the clock of given replay session, so that none of its
+// orders get published until it's resumed
+func (q *ReplayQueue) Pause(requestId string) bool {
+	return q.control(requestId, "pause", 0)
+}
+
+// Resume - Unfreezes the clock of given replay session, shifting execution time
+// of all its pending orders by the duration it was paused for
+func (q *ReplayQueue) Resume(requestId string) bool {
+	return q.control(requestId, "resume", 0)
+}
+
+// SetRate - Changes replay rate of given replay session, rescheduling all its
+// pending orders, while already published ones remain unaffected
+func (q *ReplayQueue) SetRate(requestId string, rate float32) bool {
+	return q.control(requestId, "set_rate", rate)
+}
+
+// Seek - Drops all pending orders of given replay session, which is to be continued
+// from order with given order number, replayed right away
+func (q *ReplayQueue) Seek(requestId string, orderNumber uint64) bool {
+
+	resp := make(chan bool)
+	req := Control{
+		RequestId:    requestId,
+		Action:       "seek",
+		OrderNumber:  orderNumber,
+		ResponseChan: resp,
+	}
+
+	q.ControlChan <- req
+	return <-resp
+
+}
+
+// Cancel - Drops all pending orders of given replay session & schedules
+// replay EOF to be published right away
+func (q *ReplayQueue) Cancel(requestId string) bool {
+	return q.control(requestId, "cancel", 0)
+}
+
+// Close - Drops all pending orders of given replay session & forgets about it,
+// without publishing replay EOF
+func (q *ReplayQueue) Close(requestId string) bool {
+	return q.control(requestId, "close", 0)
+}
+
+func (q *ReplayQueue) control(requestId string, action string, rate float32) bool {
+
+	resp := make(chan bool)
+	req := Control{
+		RequestId:    requestId,
+		Action:       action,
+		Rate:         rate,
+		ResponseChan: resp,
+	}
+
+	q.ControlChan <- req
+	return <-resp
+
+}
+
 func (q *ReplayQueue) CleanUp() {
 	q.CleanUpChan <- true
 }
@@ -139,32 +279,76 @@ func (q *ReplayQueue) CleanUp() {
 // Stop - You're supposed to be stopping this method as an
 // independent go routine
 func (q *ReplayQueue) Stop() {
-	q.mutex.RLock()
-	defer q.mutex.RUnlock()
+	q.mutex.Lock()
 
 	if q.stopped {
+		q.mutex.Unlock()
 		return
 	}
 
+	// Publishers check it before waiting for next due order
 	q.stopped = true
+	q.mutex.Unlock()
 
 	q.stopChannel <- "Stop"
 	<-q.stopChannel
 
 }
 
+// IsStopped - Whether queue has been stopped or not
+func (q *ReplayQueue) IsStopped() bool {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	return q.stopped
+}
+
 // Start - You're supposed to be starting this method as an
 // independent go routine, with will listen on multiple channels
 // & respond back over provided channel ( by client )
 func (q *ReplayQueue) Start() {
 
+	// Publishers waiting for next order to be due, woken up
+	// by timer set for the order due next
+	var waiting []Next
+	// Readers waiting for their replay sessions to have room
+	// for orders read ahead
+	var admitting []Admission
+
+	timer := time.NewTimer(time.Hour)
+	stopTimer(timer)
+
 	for {
+
+		waiting = q.dispatch(waiting)
+		admitting = q.admit(admitting)
+		q.arm(timer, len(waiting) > 0, admitting)
+
 		select {
 
+		case <-timer.C:
+			// Order due next has become due, to be dispatched
+			// in the next iteration
+
 		case <-q.stopChannel:
 
 			log.Println("Stopping replay queue")
 
+			for _, nxt := range waiting {
+				nxt.ResponseChan <- struct {
+					Status 	bool
+					Order 	string
+					Time  	int64
+					EOF   	bool
+				}{
+					Status: false,
+				}
+			}
+
+			for _, adm := range admitting {
+				adm.ResponseChan <- false
+			}
+
 			q.stopChannel <- "Stop"
 			return
 
@@ -178,7 +362,36 @@ func (q *ReplayQueue) Start() {
 
 			}
 
-			q.Orders[req.Order.ID()] = &Status { Order: req.Order, Inserted: true }
+			// Orders are only accepted for live sessions, execution time of
+			// which is determined by the session clock
+			session, ok := q.sessions[req.Order.RequestId]
+			if !ok || session.Cancelled || req.Order.OrderNumber < session.Floor {
+
+				req.ResponseChan <- false
+				break
+
+			}
+
+			session.Anchor(req.Order.Timestamp)
+			req.Order.ExecuteTime = session.ExecuteTime(req.Order.Timestamp)
+			if req.Order.EOF {
+				req.Order.ExecuteTime += 1000 // 1 millisecond buffer for replay finished message
+			}
+
+			if req.Order.OrderNumber >= session.Next {
+				session.Next = req.Order.OrderNumber + 1
+			}
+
+			status := &Status { Order: req.Order, Inserted: true }
+			q.Orders[req.Order.ID()] = status
+			session.Pending++
+
+			// Clock of paused session is frozen, none of its orders are
+			// scheduled until it's resumed
+			if !session.Paused() {
+				heap.Push(&q.schedule, status)
+			}
+
 			req.ResponseChan <- true
 
 		case req := <-q.CanPublishChan:
@@ -204,63 +417,305 @@ func (q *ReplayQueue) Start() {
 			}
 
 			order.Published = true
+			delete(q.Orders, req.Order)
+
+			// Replay is over, session clock is not required anymore
+			if order.Order.EOF {
+				delete(q.sessions, order.Order.RequestId)
+			}
+
 			req.ResponseChan <- true
 
+		case req := <-q.ControlChan:
+
+			ok := q.handleControl(&req)
+
+			// Session control might have changed execution time
+			// or schedulability of any pending order
+			if ok {
+				q.rebuild()
+			}
+
+			req.ResponseChan <- ok
+
 		case nxt := <-q.PublishNextChan:
 
-			var selected string
-			var found bool
+			waiting = append(waiting, nxt)
 
-			var min int64
-			// TODO: use min heap for this
-			for k := range q.Orders {
+		case adm := <-q.AdmitChan:
 
-				if q.Orders[k].Published {
-					delete(q.Orders, k)
-					continue
-				}
+			admitting = append(admitting, adm)
 
-				if min == 0 {
-					min = q.Orders[k].Order.ExecuteTime
-				}
+		}
+	}
 
-				if q.Orders[k].Order.ExecuteTime <= time.Now().UnixMicro() {
-					if min >= q.Orders[k].Order.ExecuteTime || (min == q.Orders[k].Order.ExecuteTime && k < selected) {
-						selected = k
-						min = q.Orders[k].Order.ExecuteTime
-						found = true
-					}
-				}
+}
 
-			}
+// dispatch - Hands over due orders to publishers waiting for them, in order of
+// their execution time, returns publishers still waiting
+func (q *ReplayQueue) dispatch(waiting []Next) []Next {
 
-			if !found {
+	now := time.Now().UnixMicro()
 
-				nxt.ResponseChan <- struct {
-					Status 	bool
-					Order 	string
-					Time  	int64
-					EOF   	bool
-				}{
-					Status: false,
-				}
-				break
+	for len(waiting) > 0 {
 
-			}
+		head, ok := q.schedule.Head()
+		if !ok || head.Order.ExecuteTime > now {
+			break
+		}
 
-			nxt.ResponseChan <- struct {
-				Status 	bool
-				Order 	string
-				Time   	int64
-				EOF   	bool
-			}{
-				Status: true,
-				Order: selected,
-				Time: min,
-				EOF: q.Orders[selected].Order.EOF,
-			}
+		heap.Pop(&q.schedule)
+		head.Dispatched = true
+
+		// Making room in look-ahead window of the session
+		if session, ok := q.sessions[head.Order.RequestId]; ok && session.Pending > 0 {
+			session.Pending--
+		}
+
+		waiting[0].ResponseChan <- struct {
+			Status 	bool
+			Order 	string
+			Time   	int64
+			EOF   	bool
+		}{
+			Status: true,
+			Order: head.Order.ID(),
+			Time: head.Order.ExecuteTime,
+			EOF: head.Order.EOF,
+		}
+		waiting = waiting[1:]
+
+	}
+
+	return waiting
+}
+
+// admit - Lets readers put their orders into queue, as soon as those fit into
+// look-ahead window of their sessions, returns readers still waiting
+func (q *ReplayQueue) admit(admitting []Admission) []Admission {
+
+	pending := admitting[:0]
+
+	for _, adm := range admitting {
+
+		session, ok := q.sessions[adm.RequestId]
+		if !ok || session.Cancelled {
+			adm.ResponseChan <- false
+			continue
+		}
+
+		if q.admits(session, adm.Timestamp) {
+			adm.ResponseChan <- true
+			continue
+		}
+
+		pending = append(pending, adm)
+
+	}
+
+	return pending
+}
+
+// admits - Whether order with given original trade timestamp fits into look-ahead
+// window of given session, at this moment
+func (q *ReplayQueue) admits(session *Session, timestamp int64) bool {
+
+	if q.window > 0 && session.Pending >= q.window {
+		return false
+	}
+
+	// Clock gets anchored at first order put, so it's always admitted
+	if q.horizon == 0 || !session.Anchored {
+		return true
+	}
+
+	return timestamp <= session.Position()+q.horizon
+}
+
+// arm - Sets timer to fire when order due next becomes due, if any publisher is
+// waiting for it, or when order read ahead by any reader gets into its session's
+// look-ahead window, whichever is earlier
+func (q *ReplayQueue) arm(timer *time.Timer, waiting bool, admitting []Admission) {
+
+	stopTimer(timer)
+
+	var at int64 = 0
+
+	if head, ok := q.schedule.Head(); waiting && ok {
+		at = head.Order.ExecuteTime
+	}
+
+	for _, adm := range admitting {
+
+		// Readers blocked by number of pending orders are let in only
+		// once orders get dispatched, while clock of paused session
+		// doesn't move at all
+		session, ok := q.sessions[adm.RequestId]
+		if !ok || session.Paused() || (q.window > 0 && session.Pending >= q.window) {
+			continue
+		}
+
+		_at := session.ExecuteTime(adm.Timestamp) - int64(float64(q.horizon)/float64(session.Rate))
+		if at == 0 || _at < at {
+			at = _at
+		}
+
+	}
+
+	if at == 0 {
+		return
+	}
+
+	timer.Reset(time.Duration(at-time.Now().UnixMicro()) * time.Microsecond)
+}
+
+// stopTimer - Stops timer & drains its channel, so that it can be reset
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
+// handleControl - Applies session control request, to be invoked only
+// from queue's own go routine
+func (q *ReplayQueue) handleControl(req *Control) bool {
+
+	if req.Action == "open" {
+
+		if _, ok := q.sessions[req.RequestId]; ok {
+			return false
+		}
+
+		q.sessions[req.RequestId] = NewSession(req.RequestId, req.Rate)
+		return true
+
+	}
+
+	session, ok := q.sessions[req.RequestId]
+	if !ok {
+		return false
+	}
+
+	switch req.Action {
+
+	case "pause":
+		return session.Pause()
+
+	case "resume":
+		if !session.Resume() {
+			return false
+		}
+
+		q.reschedule(session)
+		return true
+
+	case "set_rate":
+		if !session.SetRate(req.Rate) {
+			return false
+		}
+
+		q.reschedule(session)
+		return true
+
+	case "seek":
+		if session.Cancelled {
+			return false
+		}
+
+		q.drop(session.RequestId)
+		session.Seek(req.OrderNumber)
+		return true
+
+	case "cancel":
+		if session.Cancelled {
+			return false
+		}
+
+		q.drop(session.RequestId)
+		session.Cancelled = true
+		session.PausedAt = 0
+
+		// Replay EOF for cancelled session is due right away
+		eof := Order{
+			RequestId:   session.RequestId,
+			OrderNumber: session.Next,
+			ExecuteTime: time.Now().UnixMicro(),
+			EOF:         true,
+		}
+		session.Next++
+
+		q.Orders[eof.ID()] = &Status{Order: eof, Inserted: true}
+		return true
+
+	case "close":
+		q.drop(session.RequestId)
+		delete(q.sessions, session.RequestId)
+		return true
+
+	}
+
+	return false
+}
 
+// reschedule - Recomputes execution time of all pending orders of
+// given session, as per current state of its clock
+func (q *ReplayQueue) reschedule(session *Session) {
+
+	for _, status := range q.Orders {
+
+		if status.Dispatched || status.Order.RequestId != session.RequestId {
+			continue
+		}
+
+		status.Order.ExecuteTime = session.ExecuteTime(status.Order.Timestamp)
+		if status.Order.EOF {
+			status.Order.ExecuteTime += 1000
+		}
+
+	}
+
+}
+
+// rebuild - Rebuilds schedule with all pending orders,
+// skipping ones of paused sessions
+func (q *ReplayQueue) rebuild() {
+
+	q.schedule = q.schedule[:0]
+
+	for _, status := range q.Orders {
+
+		if status.Dispatched {
+			continue
+		}
+
+		if session, ok := q.sessions[status.Order.RequestId]; ok && session.Paused() {
+			continue
+		}
+
+		q.schedule = append(q.schedule, status)
+
+	}
+
+	heap.Init(&q.schedule)
+
+}
+
+// drop - Removes all pending orders of given request from queue
+func (q *ReplayQueue) drop(requestId string) {
+
+	if session, ok := q.sessions[requestId]; ok {
+		session.Pending = 0
+	}
+
+	for k, status := range q.Orders {
+
+		if status.Order.RequestId == requestId {
+			delete(q.Orders, k)
 		}
+
 	}
 
 }
@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+// next - Order published next, as reported by replay queue
+type next struct {
+	order string
+	eof   bool
+	ok    bool
+}
+
+// publishNext - Waits for next due order in background, as publisher does
+func publishNext(replays *ReplayQueue) <-chan next {
+
+	due := make(chan next, 1)
+
+	go func() {
+		order, _, eof, ok := replays.PublishNext()
+		due <- next{order: order, eof: eof, ok: ok}
+	}()
+
+	return due
+}
+
+// await - Expects given order to be due within timeout
+func await(t *testing.T, due <-chan next, order string, eof bool, timeout time.Duration) {
+	t.Helper()
+
+	select {
+
+	case got := <-due:
+		if !got.ok || got.order != order || got.eof != eof {
+			t.Fatalf("expected %s ( eof %t ) to be due, got %+v", order, eof, got)
+		}
+
+	case <-time.After(timeout):
+		t.Fatalf("expected %s to be due within %s", order, timeout)
+
+	}
+}
+
+func TestReplayQueueControl(t *testing.T) {
+
+	replays := NewReplayQueue(0, 0)
+	go replays.Start()
+	defer replays.Stop()
+
+	if replays.Pause("req") {
+		t.Fatal("Pause : expected unknown session not to be paused")
+	}
+
+	if !replays.Open("req", 1) || replays.Open("req", 1) {
+		t.Fatal("Open : expected session to be opened only once")
+	}
+
+	// First order is due right away, next one 300ms of trade time later
+	for i, timestamp := range []int64{1_000_000, 1_300_000} {
+		if !replays.Put(Order{RequestId: "req", OrderNumber: uint64(i), Timestamp: timestamp}) {
+			t.Fatalf("Put : expected order %d to be accepted", i)
+		}
+	}
+
+	await(t, publishNext(replays), "req:0", false, time.Second)
+	replays.Published("req:0")
+
+	if !replays.Pause("req") || replays.Pause("req") {
+		t.Fatal("Pause : expected running session to be paused only once")
+	}
+
+	// Nothing is due while paused, even past its execution time
+	due := publishNext(replays)
+
+	select {
+	case got := <-due:
+		t.Fatalf("expected nothing to be due while paused, got %+v", got)
+	case <-time.After(400 * time.Millisecond):
+	}
+
+	if !replays.Resume("req") || replays.Resume("req") {
+		t.Fatal("Resume : expected paused session to be resumed only once")
+	}
+
+	await(t, due, "req:1", false, time.Second)
+	replays.Published("req:1")
+
+	// Stopped replay skips its pending orders, with EOF due right away
+	if !replays.Put(Order{RequestId: "req", OrderNumber: 2, Timestamp: 60_000_000}) {
+		t.Fatal("Put : expected order 2 to be accepted")
+	}
+
+	if !replays.Cancel("req") || replays.Cancel("req") {
+		t.Fatal("Cancel : expected session to be cancelled only once")
+	}
+
+	await(t, publishNext(replays), "req:3", true, time.Second)
+	replays.Published("req:3")
+
+	// Cancelled session doesn't accept orders anymore
+	if replays.Put(Order{RequestId: "req", OrderNumber: 4, Timestamp: 1_400_000}) {
+		t.Fatal("Put : expected order of cancelled session to be rejected")
+	}
+
+}
@@ -2,32 +2,41 @@ package queue
 
 import (
 	"bufio"
+	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math"
-	"os"
-	"strconv"
 	"sync"
-	"time"
 
+	"github.com/denniswon/tcex/app/broker"
 	d "github.com/denniswon/tcex/app/data"
 	ps "github.com/denniswon/tcex/app/pubsub"
-	"github.com/go-redis/redis/v8"
+	"github.com/denniswon/tcex/app/source"
 )
 
+// ErrInvalid - Request, which can't be replayed
+var ErrInvalid = errors.New("invalid request")
+
+// ErrDuplicate - Request id already used by another request, which is queued or being replayed
+var ErrDuplicate = errors.New("duplicate request id")
+
+// ErrGone - Input file of request deleted, since request got resolved
+var ErrGone = errors.New("input file is gone")
+
 type Order struct {
 	RequestId   string
 	OrderNumber uint64
-	ExecuteTime int64
+	Timestamp   int64 // original trade timestamp, in microseconds
+	ExecuteTime int64 // determined by replay session clock, in microseconds
 	EOF         bool
 }
 
 func (o *Order) String() string {
-	return fmt.Sprintf(`{"request_id":%s,"order_number":%d,"execute_time":%d, "eof":%t}`,
+	return fmt.Sprintf(`{"request_id":%s,"order_number":%d,"timestamp":%d,"execute_time":%d, "eof":%t}`,
 		o.RequestId,
 		o.OrderNumber,
+		o.Timestamp,
 		o.ExecuteTime,
 		o.EOF,
 	)
@@ -37,17 +46,45 @@ func (o *Order) ID() string {
 	return fmt.Sprintf("%s:%d", o.RequestId, o.OrderNumber)
 }
 
-type RequestError struct {
-	RequestId string
-	Err       error
+// Cursor - Reading progress of input file, for a started request
+type Cursor struct {
+	Next  uint64        // order number to be assigned to next order read
+	Loops uint64        // number of times replay has started over
+	Shift int64         // shift applied to trade timestamps of current loop, in milliseconds
+	Span  int64         // span of replay window in milliseconds, known once it's been read till the end
+	Rate  float32       // current replay rate, which request started with, unless it's been changed since
+	Wake  chan struct{} // notified when reading is to be restarted from pending seek target
+	Done  chan struct{} // closed when request is not to be read anymore
+
+	Decoder *source.Decoder // decodes lines of input file, as per request's file format
+}
+
+// NewCursor - Cursor for request, which is about to be started at given replay rate
+func NewCursor(rate float32) *Cursor {
+	return &Cursor{
+		Rate: rate,
+		Wake: make(chan struct{}, 1),
+		Done: make(chan struct{}),
+	}
+}
+
+// wake - Lets reader of the request know about pending seek target,
+// without blocking, if it's already been notified
+func (c *Cursor) wake() {
+	select {
+	case c.Wake <- struct{}{}:
+	default:
+	}
 }
 
-func (m *RequestError) Error() string {
-	return m.Err.Error()
+// Pinner - Keeps input files from being deleted, while they're being replayed
+type Pinner interface {
+	Pin(path string) bool
+	Unpin(path string)
 }
 
 type FileRef struct {
-	File *os.File
+	File *source.File
 	RC   uint64
 }
 
@@ -56,69 +93,304 @@ type RequestQueue struct {
 	stopped        bool
 	requests       map[string]*ps.SubscriptionRequest
 	files          map[string]*FileRef
+	cursors        map[string]*Cursor
+	failures       map[string]chan error // where failure of each request is reported, till it's released
+	seeks          map[string]int64      // pending seek target timestamp ( in milliseconds ), per request
 	requestChannel chan string
 	stopChannel    chan string
 	orderChannel   chan Order
-	errorChannel   chan RequestError
-	redis          *redis.Client
+	done           chan struct{} // closed once queue is stopped, so that readers stop submitting orders
+	replays        *ReplayQueue
+	broker         broker.Broker
+	pinner         Pinner // pins input file of each request, till it's released
 	mutex          *sync.RWMutex
 }
 
 // NewClient creates a client that uses the given RPC client.
-func NewRequestQueue(_redis *redis.Client) *RequestQueue {
+func NewRequestQueue(_broker broker.Broker, replays *ReplayQueue) *RequestQueue {
 	client := &RequestQueue{
 		stopped:        false,
 		stopChannel:    make(chan string, 1),
-		errorChannel:   make(chan RequestError),
 		requestChannel: make(chan string),
 		files:          make(map[string]*FileRef),
 		requests:       make(map[string]*ps.SubscriptionRequest),
-		redis:          _redis,
+		cursors:        make(map[string]*Cursor),
+		failures:       make(map[string]chan error),
+		seeks:          make(map[string]int64),
+		done:           make(chan struct{}),
+		replays:        replays,
+		broker:         _broker,
 		mutex:          &sync.RWMutex{},
 	}
 	return client
 }
 
-func (q *RequestQueue) Put(request *ps.SubscriptionRequest) bool {
+// Reserve - Registers request to be replayed under its id, which no other request can use
+// till this one is released, returning channel its failure is reported over, only to the
+// client of this request, which is closed once request is released
+//
+// Request is started only once it's put, so that its client can subscribe to its
+// replay in the meantime, otherwise it's to be removed
+func (q *RequestQueue) Reserve(request *ps.SubscriptionRequest) (<-chan error, error) {
 
 	if !request.Validate() {
-		q.Error(request.ID, fmt.Errorf("invalid request"))
-		return false
+		log.Printf("[!] Invalid request : %s\n", request.ID)
+		return nil, ErrInvalid
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	// Replay is published over topic named after request id, so
+	// it can't be shared with request of any other client
+	if _, ok := q.requests[request.ID]; ok {
+		log.Printf("[!] Duplicate request : %s\n", request.ID)
+		return nil, ErrDuplicate
 	}
 
+	// Input file might have been deleted, since request got resolved
+	if q.pinner != nil && !q.pinner.Pin(request.Path) {
+		log.Printf("[!] Input file of request %s is gone : %s\n", request.ID, request.Path)
+		return nil, ErrGone
+	}
+
+	failed := make(chan error, 1)
+
 	q.requests[request.ID] = request
-	q.requestChannel <- request.ID
+	q.failures[request.ID] = failed
+
+	return failed, nil
+}
+
+// Put - Queues reserved request to be started, returns false if it's been released meanwhile
+func (q *RequestQueue) Put(requestId string) bool {
+
+	if !q.active(requestId) {
+		return false
+	}
+
+	q.requestChannel <- requestId
 
 	return true
 }
 
+// SetPinner - Pins input file of each request with given pinner, for as long as it's queued
+func (q *RequestQueue) SetPinner(pinner Pinner) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.pinner = pinner
+}
+
 func (q *RequestQueue) Remove(requestId string) {
 
-	q.mutex.RLock()
-	defer q.mutex.RUnlock()
+	// Pending orders of this request are not to be replayed anymore
+	q.replays.Close(requestId)
+
+	q.release(requestId)
+	q.evict(requestId)
+
+}
+
+// Pause - Pauses replay of given request, until it's resumed
+func (q *RequestQueue) Pause(requestId string) bool {
+	return q.replays.Pause(requestId)
+}
+
+// Resume - Resumes paused replay of given request, from where it was left off
+func (q *RequestQueue) Resume(requestId string) bool {
+	return q.replays.Resume(requestId)
+}
+
+// SetRate - Changes replay rate of given request, for the rest of its replay
+func (q *RequestQueue) SetRate(requestId string, rate float32) bool {
+
+	if !q.replays.SetRate(requestId, rate) {
+		return false
+	}
+
+	// Request itself is never modified, as it's read without holding the lock,
+	// while session reopened after seeking is to keep running at this rate
+	q.mutex.Lock()
+	if cursor, ok := q.cursors[requestId]; ok {
+		cursor.Rate = rate
+	}
+	q.mutex.Unlock()
+
+	return true
+
+}
+
+// Seek - Moves replay of given request to the first order at or after given
+// original trade timestamp ( in milliseconds ), to be continued from there
+func (q *RequestQueue) Seek(requestId string, timestamp int64) bool {
+
+	q.mutex.Lock()
+
+	// Only requests already being replayed can be seeked
+	cursor, ok := q.cursors[requestId]
+	if !ok || timestamp < 0 {
+		q.mutex.Unlock()
+		return false
+	}
+
+	q.seeks[requestId] = timestamp
+	q.mutex.Unlock()
+
+	cursor.wake()
+	return true
+
+}
+
+// Loop - Starts replay of given request over, once it's reached the end of its
+// replay window, with trade timestamps shifted past the previous loop
+func (q *RequestQueue) Loop(requestId string) bool {
+
+	q.mutex.Lock()
+
+	request, ok := q.requests[requestId]
+	cursor, started := q.cursors[requestId]
+	if !ok || !started {
+		q.mutex.Unlock()
+		return false
+	}
+
+	cursor.Loops++
+	cursor.Shift += cursor.Span
+	q.seeks[requestId] = request.StartTime
+	q.mutex.Unlock()
+
+	cursor.wake()
+	return true
+
+}
+
+// Cancel - Stops replay of given request, skipping all of its remaining orders,
+// while client still receives replay EOF
+func (q *RequestQueue) Cancel(requestId string) bool {
+
+	if !q.replays.Cancel(requestId) {
+		return false
+	}
+
+	// Input file is not to be read for this request anymore
+	q.release(requestId)
+	q.evict(requestId)
+
+	return true
+
+}
+
+// Control - Applies "pause", "resume", "stop", "set_rate" or "seek" request to replay
+// it's meant for, returning whether it got applied along with confirmation message
+func (q *RequestQueue) Control(request *ps.SubscriptionRequest) (bool, string) {
+
+	switch request.Type {
+
+	case "pause":
+		return q.Pause(request.ID), fmt.Sprintf("Paused `%s`", request.ID)
+
+	case "resume":
+		return q.Resume(request.ID), fmt.Sprintf("Resumed `%s`", request.ID)
+
+	case "stop":
+		return q.Cancel(request.ID), fmt.Sprintf("Stopped `%s`", request.ID)
+
+	case "set_rate":
+		return q.SetRate(request.ID, request.ReplayRate), fmt.Sprintf("Replay rate of `%s` set to `x%f`", request.ID, request.ReplayRate)
+
+	case "seek":
+		return q.Seek(request.ID, request.Timestamp), fmt.Sprintf("Seeking `%s` to `%d`", request.ID, request.Timestamp)
+
+	}
+
+	return false, fmt.Sprintf("Unknown request type `%s`", request.Type)
+
+}
+
+// release - Forgets about given request & closes its input file,
+// if not being read for any other request
+func (q *RequestQueue) release(requestId string) {
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	// Nothing more is to be reported about this request
+	if failed, ok := q.failures[requestId]; ok {
+		close(failed)
+		delete(q.failures, requestId)
+	}
 
 	request := q.requests[requestId]
-	if request != nil {
-		delete(q.requests, requestId)
+	if request == nil {
+		return
+	}
+
+	if q.pinner != nil {
+		q.pinner.Unpin(request.Path)
 	}
 
-	if q.files[requestId] == nil {
+	cursor, started := q.cursors[requestId]
+
+	delete(q.requests, requestId)
+	delete(q.cursors, requestId)
+	delete(q.seeks, requestId)
+
+	// Reader of this request is supposed to exit
+	if started {
+		close(cursor.Done)
+	}
+
+	// Input file is acquired only once request gets started
+	if !started || q.files[request.Path] == nil {
 		return
 	}
 
-	if q.files[requestId].RC == 1 {
-		q.files[requestId].File.Close()
-		delete(q.files, requestId)
+	if q.files[request.Path].RC == 1 {
+		q.files[request.Path].File.Close()
+		delete(q.files, request.Path)
 	} else {
-		q.files[requestId].RC--
+		q.files[request.Path].RC--
+	}
+
+}
+
+// evict - Removes cached order data of given request, which
+// is not going to be replayed
+func (q *RequestQueue) evict(requestId string) {
+
+	if err := q.broker.DelPrefix(context.Background(), fmt.Sprintf("%s:", requestId)); err != nil {
+		log.Printf("Failed to evict cached orders for request %s : %s\n", requestId, err.Error())
 	}
 
 }
 
+// active - Whether given request is still supposed to be replayed
+func (q *RequestQueue) active(requestId string) bool {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	_, ok := q.requests[requestId]
+	return ok
+}
+
+// seeking - Whether given request is to be seeked, so that
+// current reading of input file is not required anymore
+func (q *RequestQueue) seeking(requestId string) bool {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	_, ok := q.seeks[requestId]
+	return ok
+}
+
 func (q *RequestQueue) Start(orderChannel chan Order) {
 
 	log.Println("Request queue started")
+
+	q.mutex.Lock()
 	q.orderChannel = orderChannel
+	q.mutex.Unlock()
 
 	for {
 		select {
@@ -140,158 +412,513 @@ func (q *RequestQueue) Start(orderChannel chan Order) {
 }
 
 func (q *RequestQueue) HandleRequest(requestId string) error {
+	q.mutex.RLock()
 	request, ok := q.requests[requestId]
+	_, started := q.cursors[requestId]
+	q.mutex.RUnlock()
 
 	if !ok {
 		return fmt.Errorf("missing request for request id : %s", requestId)
 	}
 
+	// Already being read by its own reader
+	if started {
+		return nil
+	}
+
+	if !q.replays.Open(request.ID, request.ReplayRate) {
+		return fmt.Errorf("replay session already open for request id : %s", requestId)
+	}
+
 	log.Printf("Reading input file for request id : %s\n", request.String())
 
-	if q.files[request.Filename] == nil {
-		file, err := os.Open(request.Filename)
+	q.mutex.Lock()
+	if q.files[request.Path] == nil {
+		file, err := source.Open(request.Path)
 		if err != nil {
+			q.mutex.Unlock()
 			log.Printf("Error opening file : %s\n", err.Error())
 			return err
 		}
-		q.files[request.Filename] = &FileRef{
+		q.files[request.Path] = &FileRef{
 			File: file,
 			RC:   1,
 		}
 	} else {
 
-		q.files[request.Filename].RC++
+		q.files[request.Path].RC++
+	}
+	cursor := NewCursor(request.ReplayRate)
+	q.cursors[request.ID] = cursor
+	file := q.files[request.Path].File
+	q.mutex.Unlock()
+
+	// Same input file might be read for multiple requests,
+	// each decoding it as per its own options
+	decoder, err := source.NewDecoder(file, request.Filename, request.Source())
+	if err != nil {
+		log.Printf("Error reading input file header : %s\n", err.Error())
+		return err
+	}
+
+	q.mutex.Lock()
+	cursor.Decoder = decoder
+	q.mutex.Unlock()
+
+	// Input file is read only as fast as replay progresses, so each
+	// request gets its own reader, not to block others
+	go q.stream(request, cursor)
+
+	return nil
+}
+
+// stream - Reads input file for given request, from the start of its replay
+// window & then from each seek target, until request is gone
+func (q *RequestQueue) stream(request *ps.SubscriptionRequest, cursor *Cursor) {
+
+	from := request.StartTime
+
+	for {
+
+		if err := q.Run(request, from); err != nil {
+			q.Error(request.ID, err)
+			return
+		}
+
+		target, ok := q.await(request.ID, cursor)
+		if !ok {
+			return
+		}
+
+		q.mutex.RLock()
+		next := cursor.Next
+		rate := cursor.Rate
+		q.mutex.RUnlock()
+
+		log.Printf("Reading input file from %d for request id : %s\n", target, request.String())
+
+		// Replay might have already finished, in that case
+		// it's to be continued in a new session
+		if !q.replays.Seek(request.ID, next) && !q.replays.Open(request.ID, rate) {
+			q.Error(request.ID, fmt.Errorf("failed to seek replay session for request id : %s", request.ID))
+			return
+		}
+
+		// Cached orders read before seeking are not to be replayed anymore
+		q.evict(request.ID)
+
+		from = target
+
 	}
+}
+
+// await - Waits for next seek target of given request, returns
+// false once request is not to be read anymore
+func (q *RequestQueue) await(requestId string, cursor *Cursor) (int64, bool) {
+
+	for {
+
+		select {
+
+		case <-cursor.Done:
+			return 0, false
+
+		case <-cursor.Wake:
+
+		}
+
+		// Latest seek target might have already been handled
+		q.mutex.Lock()
+		target, ok := q.seeks[requestId]
+		delete(q.seeks, requestId)
+		q.mutex.Unlock()
 
-	return q.Run(request)
+		if ok {
+			return target, true
+		}
+
+	}
 }
 
-func (q *RequestQueue) Run(request *ps.SubscriptionRequest) error {
-	if q.files[request.Filename] == nil {
-		return fmt.Errorf("missing file : %s", request.Filename)
+// Run - Reads input file for given request & submits orders for replay, starting
+// from the first trade at or after given timestamp ( in milliseconds )
+func (q *RequestQueue) Run(request *ps.SubscriptionRequest, from int64) error {
+	q.mutex.RLock()
+	fref := q.files[request.Path]
+	cursor, ok := q.cursors[request.ID]
+	q.mutex.RUnlock()
+
+	if fref == nil || !ok {
+		return fmt.Errorf("missing file : %s", request.Path)
+	}
+
+	q.mutex.RLock()
+	orderNumber := cursor.Next
+	shift := cursor.Shift
+	loops := cursor.Loops
+	decoder := cursor.Decoder
+	q.mutex.RUnlock()
+
+	// Order numbers read so far are never to be reused, even if
+	// reading gets interrupted by seek
+	defer func() {
+		q.mutex.Lock()
+		cursor.Next = orderNumber
+		q.mutex.Unlock()
+	}()
+
+	// Nothing before replay window is to be replayed
+	if from < request.StartTime {
+		from = request.StartTime
+	}
+
+	// In-progress kline bucket is rebuilt by reading input file from its start, unless
+	// klines are built only from warmup position or replay window start, which is later
+	//
+	// Where other bars start depends on all trades before, so those are always rebuilt
+	// from warmup position or replay window start
+	origin := from
+	if request.Name == "kline" {
+		origin = request.StartTime
+		if request.WarmupFrom > 0 {
+			origin = request.WarmupFrom
+		}
+
+		if start := bucketsStart(from, request.Granularities); request.BarType == "time" && start > origin {
+			origin = start
+		}
+	}
+
+	// Header line of input file is never read as trade
+	var offset int64 = decoder.Start
+
+	if origin > 0 {
+		_offset, err := seekTimestamp(fref.File, decoder, origin)
+		if err != nil {
+			log.Printf("Failed to seek input file to %d : %s\n", origin, err.Error())
+			return err
+		}
+
+		offset = _offset
 	}
 
-	fref := q.files[request.Filename]
-	fref.File.Seek(0, 0)
-	scanner := bufio.NewScanner(fref.File)
+	// Same input file might be read for multiple requests at once,
+	// so it's read at offset, without moving shared file offset
+	reader, err := fref.File.Section(offset)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
 
-	var orderNumber uint64 = 0
-	var currTime int64 = time.Now().UnixMicro()
-	var indexTime int64 = 0
-	var lastOrderExecuteTime int64 = 0
-	var pairs []interface{}
-	orders := []Order{}
+	var lastOrderTimestamp int64 = from
 
-	var kline d.Kline = d.Kline{
-		Granularity: request.Granularity,
+	// In-progress bucket of each granularity, all built from same trades,
+	// or in-progress bar of any other type
+	var klines []d.Kline
+	if request.Name == "kline" {
+		klines = make([]d.Kline, 1)
+		if request.BarType == "time" {
+			klines = make([]d.Kline, len(request.Granularities))
+		}
 	}
 
 	for scanner.Scan() {
 
-		if q.IsStopped() {
-			return nil
+		// Blank lines are skipped, same as when seeking
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
 		}
 
-		var order d.Order
-		err := json.Unmarshal([]byte(scanner.Text()), &order)
+		order, err := decoder.Decode(scanner.Bytes())
 		if err != nil {
-			log.Printf("Failed to decode order data to JSON : %s\n", err.Error())
+			log.Printf("Failed to decode order data : %s\n", err.Error())
 			return err
 		}
 
-		if indexTime == 0 {
-			indexTime = order.Timestamp * 1000 // convert to microseconds
+		// Compressed input file is read from its start, as it
+		// can't be seeked, so earlier trades are skipped here
+		if order.Timestamp < origin {
+			continue
 		}
 
-		f, _ := strconv.ParseFloat(order.Price, 32)
-		price := float64(f)
+		// Trades are sorted by timestamp, nothing after
+		// replay window is to be read
+		if request.EndTime > 0 && order.Timestamp > request.EndTime {
+			break
+		}
 
-		if kline.Timestamp == 0 || kline.Timestamp+int64(request.Granularity*1000) <= order.Timestamp {
-			kline.Timestamp = order.Timestamp
+		if request.Name == "kline" && request.BarType == "time" {
 
-			kline.Low = price
-			kline.High = price
-			kline.Open = price
-			kline.Close = price
+			for i, granularity := range request.Granularities {
+
+				kline := &klines[i]
+				start := bucketStart(order.Timestamp, granularity)
+
+				// Bucket might start at 0, so it's open once it's got granularity
+				if kline.Granularity != 0 && start == kline.Timestamp {
+					addTrade(kline, &order)
+					continue
+				}
+
+				// Previous bucket gets closed right when this one begins
+				if ok, err := q.advance(cursor, request, kline, start, from, shift, &orderNumber); !ok {
+					return err
+				}
+
+				*kline = newKline(&order, granularity)
 
-			if order.Aggressor == "ask" {
-				kline.Volume = int64(order.Quantity) * -1
-			} else {
-				kline.Volume = int64(order.Quantity)
 			}
 
-			kline.Turnover = price * float64(order.Quantity)
-		} else {
-			kline.Low = math.Min(kline.Low, price)
-			kline.High = math.Max(kline.Low, price)
-			kline.Close = price
+		} else if request.Name == "kline" {
 
-			if order.Aggressor == "ask" {
-				kline.Volume -= int64(order.Quantity)
+			// Bar is closed with the trade, which makes it reach threshold
+			bar := &klines[0]
+			if bar.BarType == "" || bar.Closed {
+				*bar = newBar(&order, request.BarType, request.Threshold)
 			} else {
-				kline.Volume += int64(order.Quantity)
+				addTrade(bar, &order)
 			}
 
-			kline.Turnover += price * float64(order.Quantity)
+			bar.Closed = barFull(bar)
+
+		}
+
+		// Trades before replay position ( i.e. warmup trades or ones skipped by seek )
+		// only make up in-progress kline bucket
+		if order.Timestamp < from {
+			continue
 		}
 
-		pairs = append(pairs, fmt.Sprintf("%s:%d", request.ID, orderNumber))
+		lastOrderTimestamp = order.Timestamp
 
+		// Timestamps keep increasing, when replay has started over
 		switch request.Name {
 		case "kline":
-			pairs = append(pairs, kline.ToJSON())
+
+			// Bucket of each granularity is replayed on its own
+			for i := range klines {
+				if ok, err := q.submitKline(cursor, request, klines[i], lastOrderTimestamp, shift, &orderNumber); !ok {
+					return err
+				}
+			}
+
 		case "order":
-			pairs = append(pairs, order.ToJSON())
-		}
+			order.Timestamp += shift
+
+			ok, err := q.submit(cursor, Order{
+				RequestId:   request.ID,
+				OrderNumber: orderNumber,
+				Timestamp:   (lastOrderTimestamp + shift) * 1000, // convert to microseconds
+				EOF:         false,
+			}, order.ToJSON())
+			if !ok {
+				return err
+			}
 
-		lastOrderExecuteTime = currTime + int64(float32(order.Timestamp*1000-indexTime)/request.ReplayRate)
+			orderNumber++
+		}
 
-		orders = append(orders, Order{
-			RequestId:   request.ID,
-			OrderNumber: orderNumber,
-			ExecuteTime: lastOrderExecuteTime,
-			EOF:         false,
-		})
+	}
 
-		orderNumber++
+	if err := scanner.Err(); err != nil {
+		log.Printf("Failed to read input file : %s\n", err.Error())
+		return err
+	}
 
+	// Replay of a window finishes right at the end of it, so last
+	// kline bucket is closed, if it's over by then
+	if request.EndTime > 0 {
+		lastOrderTimestamp = request.EndTime
+
+		// Bars other than time buckets are closed only by trades
+		for i := range klines {
+			if request.BarType == "time" {
+				if ok, err := q.advance(cursor, request, &klines[i], request.EndTime, from, shift, &orderNumber); !ok {
+					return err
+				}
+			}
+		}
 	}
 
 	// dummy last order to signal replay finished
-	orders = append(orders, Order{
+	last := Order{
 		RequestId:   request.ID,
 		OrderNumber: orderNumber,
-		ExecuteTime: lastOrderExecuteTime + 1000, // 1 millisecond buffer for replay finished message
+		Timestamp:   (lastOrderTimestamp + shift) * 1000,
 		EOF:         true,
-	})
+	}
+	var payload []byte
 
-	if len(pairs) > 0 {
-		_, err := q.redis.MSet(context.Background(), pairs...).Result()
-		if err != nil {
+	if request.Loop == ps.LoopForever || int64(loops)+1 < int64(request.Loop) {
+
+		// Replay is to be started over once this loop is over, so next
+		// loop's trade timestamps are shifted past this one
+		start := request.StartTime
+		if _start, ok, err := firstTimestamp(fref.File, decoder, start); err == nil && ok {
+			start = _start
+		}
+
+		span := lastOrderTimestamp - start + 1
+
+		// Kline buckets of next loop stay aligned to granularity
+		if request.Name == "kline" && request.BarType == "time" {
+			size, _ := ps.Alignment(request.Granularities)
+			span = (span + size - 1) / size * size
+		}
+
+		q.mutex.Lock()
+		cursor.Span = span
+		q.mutex.Unlock()
+
+		loop := d.Loop{
+			RequestID: request.ID,
+			Loop:      loops + 1,
+		}
+
+		// loop marker to signal replay starting over
+		last.EOF = false
+		payload = loop.ToJSON()
+
+	}
+
+	if ok, err := q.submit(cursor, last, payload); !ok {
+		return err
+	}
+
+	orderNumber++
+
+	return nil
+}
+
+// advance - Moves kline stream of given request forward to given original trade timestamp,
+// closing buckets which are over by then & when gap filling is requested, opening empty
+// ones, which have begun before that. Buckets over before replay position are not replayed
+func (q *RequestQueue) advance(cursor *Cursor, request *ps.SubscriptionRequest, kline *d.Kline, until int64, from int64, shift int64, orderNumber *uint64) (bool, error) {
+
+	for kline.Granularity != 0 && bucketEnd(kline) <= until {
+
+		end := bucketEnd(kline)
+
+		// Bucket gets closed right when next one begins
+		if end > from {
+			_kline := *kline
+			_kline.Closed = true
+
+			if ok, err := q.submitKline(cursor, request, _kline, end, shift, orderNumber); !ok {
+				return false, err
+			}
+		}
+
+		if !request.FillGaps || end >= until {
+			*kline = d.Kline{}
+			break
+		}
+
+		// No trade falls in next bucket, so it's carrying previous close price
+		*kline = emptyKline(kline.Close, end, kline.Granularity)
+
+		if bucketEnd(kline) > from {
+			at := end
+			if at < from {
+				at = from
+			}
+
+			if ok, err := q.submitKline(cursor, request, *kline, at, shift, orderNumber); !ok {
+				return false, err
+			}
+		}
+
+	}
+
+	return true, nil
+
+}
+
+// submitKline - Submits given kline bucket, to be replayed at given original
+// trade timestamp ( in milliseconds ) & moves to next order number
+func (q *RequestQueue) submitKline(cursor *Cursor, request *ps.SubscriptionRequest, kline d.Kline, at int64, shift int64, orderNumber *uint64) (bool, error) {
+
+	kline.Timestamp += shift
+
+	ok, err := q.submit(cursor, Order{
+		RequestId:   request.ID,
+		OrderNumber: *orderNumber,
+		Timestamp:   (at + shift) * 1000,
+		EOF:         false,
+	}, kline.ToJSON())
+	if !ok {
+		return false, err
+	}
+
+	*orderNumber++
+	return true, nil
+
+}
+
+// submit - Waits till given order fits into look-ahead window of its replay session,
+// then caches its data ( if any ) & submits it for replay
+//
+// Returns false if request is not to be read anymore, either because it's been stopped,
+// seeked or failed to cache order data
+func (q *RequestQueue) submit(cursor *Cursor, order Order, data []byte) (bool, error) {
+
+	if q.IsStopped() || !q.active(order.RequestId) || q.seeking(order.RequestId) {
+		return false, nil
+	}
+
+	if !q.replays.Admit(order.RequestId, order.Timestamp, cursor.Wake) {
+		// Reader might have been woken up for seeking, which is
+		// to be handled once current reading is given up
+		cursor.wake()
+		return false, nil
+	}
+
+	// Replay might have been stopped or seeked while waiting
+	if q.IsStopped() || !q.active(order.RequestId) || q.seeking(order.RequestId) {
+		return false, nil
+	}
+
+	if data != nil {
+		if err := q.broker.Set(context.Background(), order.ID(), data); err != nil {
 			log.Printf("Failed to cache order for request %s order number %d : %s\n",
-				request.ID, orderNumber, err.Error(),
+				order.RequestId, order.OrderNumber, err.Error(),
 			)
-			return err
+			return false, err
 		}
 	}
 
-	for _, order := range orders {
-		q.orderChannel <- order
+	q.mutex.RLock()
+	orders := q.orderChannel
+	q.mutex.RUnlock()
+
+	// Nobody's receiving orders anymore, once queue is stopped
+	select {
+	case orders <- order:
+		return true, nil
+	case <-q.done:
+		return false, nil
 	}
 
-	return nil
 }
 
+// Stop - Stops handling requests, while readers of requests being
+// replayed give up submitting their orders
 func (q *RequestQueue) Stop() {
-	q.mutex.RLock()
-	defer q.mutex.RUnlock()
+	q.mutex.Lock()
 
 	if q.stopped {
+		q.mutex.Unlock()
 		return
 	}
 
 	q.stopped = true
-	q.orderChannel = nil
+	close(q.done)
+
+	// Lock is not held while waiting, as handling of
+	// request in progress might need it to complete
+	q.mutex.Unlock()
 
 	q.stopChannel <- "Stop"
 	<-q.stopChannel
@@ -302,47 +929,57 @@ func (q *RequestQueue) Close() {
 		q.Stop()
 	}
 
-	q.mutex.RLock()
-	defer q.mutex.RUnlock()
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
 
-	for k := range q.requests {
+	for k, request := range q.requests {
+		if q.pinner != nil {
+			q.pinner.Unpin(request.Path)
+		}
 		delete(q.requests, k)
 	}
 
+	// Readers of all requests are supposed to exit
+	for k, cursor := range q.cursors {
+		close(cursor.Done)
+		delete(q.cursors, k)
+	}
+
 	for k := range q.files {
 		_ = q.files[k].File.Close()
 		delete(q.files, k)
 	}
 
+	for k, failed := range q.failures {
+		close(failed)
+		delete(q.failures, k)
+	}
+
 	if q.stopChannel != nil {
 		close(q.stopChannel)
 		q.stopChannel = nil
 	}
-	if q.errorChannel != nil {
-		close(q.errorChannel)
-		q.errorChannel = nil
-	}
 	if q.requestChannel != nil {
 		close(q.requestChannel)
 		q.requestChannel = nil
 	}
 }
 
+// Error - Reports failure of given request to its client & releases it, without
+// ever blocking, as it might not be listened for anymore
 func (q *RequestQueue) Error(requestId string, err error) {
-	request, ok := q.requests[requestId]
-	if ok {
-		delete(q.requests, requestId)
-		delete(q.files, request.Filename)
-	}
+	q.replays.Close(requestId)
 
-	q.errorChannel <- RequestError{
-		RequestId: requestId,
-		Err:       err,
+	q.mutex.Lock()
+	if failed, ok := q.failures[requestId]; ok {
+		select {
+		case failed <- err:
+		default:
+		}
 	}
-}
+	q.mutex.Unlock()
 
-func (q *RequestQueue) Err() chan RequestError {
-	return q.errorChannel
+	q.release(requestId)
 }
 
 func (q *RequestQueue) IsStopped() bool {
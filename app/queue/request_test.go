@@ -0,0 +1,606 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/denniswon/tcex/app/broker"
+	d "github.com/denniswon/tcex/app/data"
+	ps "github.com/denniswon/tcex/app/pubsub"
+	"github.com/denniswon/tcex/app/source"
+)
+
+// trade - Trade of input file, at given timestamp in milliseconds
+type trade struct {
+	timestamp int64
+	price     string
+	quantity  uint64
+	aggressor string
+}
+
+// writeTrades - Writes input file holding given trades, one JSON encoded trade per line
+func writeTrades(t *testing.T, trades []trade) string {
+	t.Helper()
+
+	var lines strings.Builder
+	for _, _trade := range trades {
+
+		aggressor := _trade.aggressor
+		if aggressor == "" {
+			aggressor = "bid"
+		}
+
+		quantity := _trade.quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+
+		fmt.Fprintf(&lines, `{"price":"%s","quantity":%d,"aggressor":"%s","timestamp":%d}`+"\n",
+			_trade.price, quantity, aggressor, _trade.timestamp)
+
+	}
+
+	path := filepath.Join(t.TempDir(), "trades.jsonl")
+	if err := os.WriteFile(path, []byte(lines.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// newRequest - Subscription request replaying given input file,
+// with defaults filled in, same as clients' requests get
+func newRequest(path string, req ps.SubscriptionRequest) *ps.SubscriptionRequest {
+
+	req.ID = "req"
+	req.Type = "subscribe"
+	req.Filename = filepath.Base(path)
+	req.Path = path
+
+	return req.Generate()
+}
+
+// replayed - Order submitted for replay, along with its cached data
+type replayed struct {
+	Order
+	data string
+}
+
+// kline - Replayed kline bucket, formatted as `timestamp open/high/low/close` with
+// `closed` appended if it's over, followed by granularity & replay position
+func (r replayed) kline(t *testing.T) string {
+	t.Helper()
+
+	if r.EOF {
+		return fmt.Sprintf("eof @%d", r.Timestamp/1000)
+	}
+
+	var kline d.Kline
+	if err := json.Unmarshal([]byte(r.data), &kline); err != nil {
+		t.Fatalf("order %d : bad kline %q : %s", r.OrderNumber, r.data, err)
+	}
+
+	closed := ""
+	if kline.Closed {
+		closed = " closed"
+	}
+
+	return fmt.Sprintf("%d %g/%g/%g/%g%s g%d @%d",
+		kline.Timestamp, kline.Open, kline.High, kline.Low, kline.Close, closed, kline.Granularity, r.Timestamp/1000)
+}
+
+// trade - Replayed trade, formatted as `timestamp price` followed by replay position
+func (r replayed) trade(t *testing.T) string {
+	t.Helper()
+
+	if r.EOF {
+		return fmt.Sprintf("eof @%d", r.Timestamp/1000)
+	}
+
+	if strings.Contains(r.data, `"loop"`) {
+		var loop d.Loop
+		if err := json.Unmarshal([]byte(r.data), &loop); err != nil {
+			t.Fatalf("order %d : bad loop marker %q : %s", r.OrderNumber, r.data, err)
+		}
+
+		return fmt.Sprintf("loop %d @%d", loop.Loop, r.Timestamp/1000)
+	}
+
+	var order d.Order
+	if err := json.Unmarshal([]byte(r.data), &order); err != nil {
+		t.Fatalf("order %d : bad trade %q : %s", r.OrderNumber, r.data, err)
+	}
+
+	return fmt.Sprintf("%d %s @%d", order.Timestamp, order.Price, r.Timestamp/1000)
+}
+
+// testQueue - Request queue, whose requests are read by tests themselves, submitting
+// orders to buffered channel, while replay queue runs on its own
+type testQueue struct {
+	*RequestQueue
+	replays *ReplayQueue
+	broker  *broker.Memory
+}
+
+func newTestQueue(t *testing.T) *testQueue {
+
+	replays := NewReplayQueue(0, 0)
+	go replays.Start()
+	t.Cleanup(replays.Stop)
+
+	_broker := broker.NewMemory()
+
+	requests := NewRequestQueue(_broker, replays)
+	requests.orderChannel = make(chan Order, 1024)
+
+	return &testQueue{RequestQueue: requests, replays: replays, broker: _broker}
+}
+
+// open - Starts given request same as request queue does, except for reading it
+func (tq *testQueue) open(t *testing.T, request *ps.SubscriptionRequest) *Cursor {
+	t.Helper()
+
+	file, err := source.Open(request.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	decoder, err := source.NewDecoder(file, request.Filename, request.Source())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cursor := NewCursor(request.ReplayRate)
+	cursor.Decoder = decoder
+
+	tq.mutex.Lock()
+	tq.requests[request.ID] = request
+	tq.files[request.Path] = &FileRef{File: file, RC: 1}
+	tq.cursors[request.ID] = cursor
+	tq.mutex.Unlock()
+
+	if !tq.replays.Open(request.ID, request.ReplayRate) {
+		t.Fatalf("failed to open replay session of %s", request.ID)
+	}
+
+	return cursor
+}
+
+// run - Reads input file of given request from given timestamp, returning orders submitted
+func (tq *testQueue) run(t *testing.T, request *ps.SubscriptionRequest, from int64) []replayed {
+	t.Helper()
+
+	if err := tq.Run(request, from); err != nil {
+		t.Fatalf("Run(%d) : %s", from, err)
+	}
+
+	var orders []replayed
+
+	for {
+		select {
+
+		case order := <-tq.orderChannel:
+
+			data, err := tq.broker.Get(context.Background(), order.ID())
+			if err != nil && !order.EOF {
+				t.Fatalf("order %s : missing cached data : %s", order.ID(), err)
+			}
+
+			orders = append(orders, replayed{Order: order, data: data})
+
+		default:
+			return orders
+		}
+	}
+}
+
+// expect - Compares formatted orders with expected ones
+func expect(t *testing.T, got []string, expected []string) {
+	t.Helper()
+
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected\n\t%s\ngot\n\t%s", strings.Join(expected, "\n\t"), strings.Join(got, "\n\t"))
+	}
+}
+
+func TestRunSeekMidBucket(t *testing.T) {
+
+	path := writeTrades(t, []trade{
+		{timestamp: 59_000, price: "2"},
+		{timestamp: 60_000, price: "3"},
+		{timestamp: 61_000, price: "1.5"},
+		{timestamp: 62_000, price: "4"},
+		{timestamp: 121_000, price: "5"},
+	})
+
+	tests := []struct {
+		name     string
+		from     int64
+		expected []string
+	}{
+		{
+			name: "from bucket start",
+			from: 60_000,
+			expected: []string{
+				"60000 3/3/3/3 g60 @60000",
+				"60000 3/3/1.5/1.5 g60 @61000",
+				"60000 3/4/1.5/4 g60 @62000",
+				"60000 3/4/1.5/4 closed g60 @120000",
+				"120000 5/5/5/5 g60 @121000",
+				"eof @121000",
+			},
+		},
+		{
+			// Bucket seeked into is rebuilt from its start, while it's
+			// replayed only from the first trade after seek target
+			name: "mid bucket",
+			from: 61_500,
+			expected: []string{
+				"60000 3/4/1.5/4 g60 @62000",
+				"60000 3/4/1.5/4 closed g60 @120000",
+				"120000 5/5/5/5 g60 @121000",
+				"eof @121000",
+			},
+		},
+		{
+			name: "past last bucket",
+			from: 122_000,
+			expected: []string{
+				"eof @122000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			tq := newTestQueue(t)
+			request := newRequest(path, ps.SubscriptionRequest{Name: "kline", Granularity: 60})
+			tq.open(t, request)
+
+			var got []string
+			for _, order := range tq.run(t, request, tt.from) {
+				got = append(got, order.kline(t))
+			}
+
+			expect(t, got, tt.expected)
+
+		})
+	}
+
+}
+
+func TestRunOrderNumbers(t *testing.T) {
+
+	path := writeTrades(t, []trade{
+		{timestamp: 1_000, price: "1"},
+		{timestamp: 2_000, price: "2"},
+		{timestamp: 3_000, price: "3"},
+	})
+
+	tq := newTestQueue(t)
+	request := newRequest(path, ps.SubscriptionRequest{Name: "order"})
+	tq.open(t, request)
+
+	// Order numbers are never reused, even if same trades are read again
+	var numbers []string
+	for _, from := range []int64{0, 2_000} {
+		for _, order := range tq.run(t, request, from) {
+			numbers = append(numbers, fmt.Sprintf("%d:%s", order.OrderNumber, order.trade(t)))
+		}
+	}
+
+	expect(t, numbers, []string{
+		"0:1000 1 @1000",
+		"1:2000 2 @2000",
+		"2:3000 3 @3000",
+		"3:eof @3000",
+		"4:2000 2 @2000",
+		"5:3000 3 @3000",
+		"6:eof @3000",
+	})
+
+}
+
+func TestLoopShift(t *testing.T) {
+
+	path := writeTrades(t, []trade{
+		{timestamp: 1_000, price: "1"},
+		{timestamp: 2_000, price: "2"},
+		{timestamp: 4_000, price: "3"},
+	})
+
+	tq := newTestQueue(t)
+	request := newRequest(path, ps.SubscriptionRequest{Name: "order", Loop: 3})
+	cursor := tq.open(t, request)
+
+	var got []string
+	from := request.StartTime
+
+	for loop := 0; loop < 3; loop++ {
+
+		for _, order := range tq.run(t, request, from) {
+			got = append(got, order.trade(t))
+		}
+
+		if loop == 2 {
+			break
+		}
+
+		// Publisher starts replay over, once loop marker gets published
+		if !tq.Loop(request.ID) {
+			t.Fatalf("Loop %d : expected replay to be started over", loop)
+		}
+
+		target, ok := tq.await(request.ID, cursor)
+		if !ok || target != request.StartTime {
+			t.Fatalf("Loop %d : expected reading from %d, got %d %t", loop, request.StartTime, target, ok)
+		}
+
+		from = target
+
+	}
+
+	// Each loop is shifted by span of trades, from first one till last one
+	expect(t, got, []string{
+		"1000 1 @1000",
+		"2000 2 @2000",
+		"4000 3 @4000",
+		"loop 1 @4000",
+		"4001 1 @4001",
+		"5001 2 @5001",
+		"7001 3 @7001",
+		"loop 2 @7001",
+		"7002 1 @7002",
+		"8002 2 @8002",
+		"10002 3 @10002",
+		"eof @10002",
+	})
+
+	if cursor.Loops != 2 || cursor.Shift != 6002 {
+		t.Fatalf("expected 2 loops shifted by 6002, got %d loops shifted by %d", cursor.Loops, cursor.Shift)
+	}
+
+}
+
+func TestStopUnblocksReader(t *testing.T) {
+
+	path := writeTrades(t, []trade{
+		{timestamp: 1_000, price: "1"},
+		{timestamp: 2_000, price: "2"},
+	})
+
+	tq := newTestQueue(t)
+
+	// Nobody receives submitted orders
+	go tq.Start(make(chan Order))
+
+	request := newRequest(path, ps.SubscriptionRequest{Name: "order"})
+	tq.open(t, request)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tq.Run(request, 0)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	tq.Stop()
+
+	select {
+
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run : expected to give up without error, got %s", err)
+		}
+
+	case <-time.After(time.Second):
+		t.Fatal("Run : still submitting after queue got stopped")
+
+	}
+
+}
+
+func TestSetRate(t *testing.T) {
+
+	path := writeTrades(t, []trade{{timestamp: 1_000, price: "1"}})
+
+	tests := []struct {
+		name    string
+		rate    float32
+		applied bool
+		cursor  float32
+	}{
+		{"faster", 120, true, 120},
+		{"slower", 0.5, true, 0.5},
+		{"zero", 0, false, 60},
+		{"negative", -1, false, 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			tq := newTestQueue(t)
+			request := newRequest(path, ps.SubscriptionRequest{Name: "order"})
+			cursor := tq.open(t, request)
+
+			if applied := tq.SetRate(request.ID, tt.rate); applied != tt.applied {
+				t.Fatalf("SetRate(%v) : expected %t, got %t", tt.rate, tt.applied, applied)
+			}
+
+			// Session reopened after seeking keeps running at changed rate,
+			// while request itself is never modified
+			if cursor.Rate != tt.cursor || request.ReplayRate != 60 {
+				t.Fatalf("SetRate(%v) : expected cursor rate %v & request rate 60, got %v & %v",
+					tt.rate, tt.cursor, cursor.Rate, request.ReplayRate)
+			}
+
+		})
+	}
+
+}
+
+// published - Order published by replay pipeline, formatted as `timestamp price` or `eof`
+type published string
+
+// start - Runs request queue along with replay queue, same as order replay publisher
+// does, returning orders of given request in the order they get published
+func (tq *testQueue) start(t *testing.T) <-chan published {
+
+	orders := make(chan Order)
+	done := make(chan struct{})
+
+	go tq.Start(orders)
+
+	t.Cleanup(func() {
+		close(done)
+		tq.Close()
+	})
+
+	go func() {
+		for {
+			select {
+
+			case <-done:
+				return
+
+			case order := <-orders:
+				tq.replays.Put(order)
+
+			}
+		}
+	}()
+
+	out := make(chan published, 1024)
+
+	go func() {
+		for {
+
+			order, _, eof, ok := tq.replays.PublishNext()
+			if !ok {
+				return
+			}
+
+			if eof {
+				tq.replays.Published(order)
+				out <- "eof"
+				continue
+			}
+
+			data, err := tq.broker.Get(context.Background(), order)
+			if err != nil {
+				continue
+			}
+
+			var _order d.Order
+			if err := json.Unmarshal([]byte(data), &_order); err != nil {
+				continue
+			}
+
+			tq.replays.Published(order)
+			out <- published(fmt.Sprintf("%d %s", _order.Timestamp, _order.Price))
+
+		}
+	}()
+
+	return out
+}
+
+// subscribe - Queues given request, same as clients do
+func (tq *testQueue) subscribe(t *testing.T, request *ps.SubscriptionRequest) {
+	t.Helper()
+
+	if _, err := tq.Reserve(request); err != nil {
+		t.Fatalf("Reserve : %s", err)
+	}
+
+	if !tq.Put(request.ID) {
+		t.Fatal("Put : expected reserved request to be queued")
+	}
+}
+
+// receive - Expects given orders to be published next, each within timeout
+func receive(t *testing.T, out <-chan published, timeout time.Duration, expected ...string) {
+	t.Helper()
+
+	for _, order := range expected {
+		select {
+
+		case got := <-out:
+			if string(got) != order {
+				t.Fatalf("expected %s to be published, got %s", order, got)
+			}
+
+		case <-time.After(timeout):
+			t.Fatalf("expected %s to be published within %s", order, timeout)
+
+		}
+	}
+}
+
+func TestSeekWhilePaused(t *testing.T) {
+
+	path := writeTrades(t, []trade{
+		{timestamp: 1_000, price: "1"},
+		{timestamp: 11_000, price: "2"},
+		{timestamp: 21_000, price: "3"},
+	})
+
+	tq := newTestQueue(t)
+	out := tq.start(t)
+
+	request := newRequest(path, ps.SubscriptionRequest{Name: "order", ReplayRate: 1})
+	tq.subscribe(t, request)
+
+	receive(t, out, time.Second, "1000 1")
+
+	if !tq.Pause(request.ID) || !tq.Seek(request.ID, 21_000) {
+		t.Fatal("expected replay to be paused & seeked")
+	}
+
+	// Seeking doesn't resume paused replay
+	select {
+	case got := <-out:
+		t.Fatalf("expected nothing to be published while paused, got %s", got)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	if !tq.Resume(request.ID) {
+		t.Fatal("expected replay to be resumed")
+	}
+
+	// Trade seeked to is due right away, instead of 20s later
+	receive(t, out, time.Second, "21000 3", "eof")
+
+}
+
+func TestSeekAfterEOF(t *testing.T) {
+
+	path := writeTrades(t, []trade{
+		{timestamp: 1_000, price: "1"},
+		{timestamp: 2_000, price: "2"},
+		{timestamp: 3_000, price: "3"},
+	})
+
+	tq := newTestQueue(t)
+	out := tq.start(t)
+
+	request := newRequest(path, ps.SubscriptionRequest{Name: "order", ReplayRate: 1000})
+	tq.subscribe(t, request)
+
+	receive(t, out, time.Second, "1000 1", "2000 2", "3000 3", "eof")
+
+	// Finished replay is continued in a new session
+	if !tq.Seek(request.ID, 2_000) {
+		t.Fatal("expected finished replay to be seeked")
+	}
+
+	receive(t, out, time.Second, "2000 2", "3000 3", "eof")
+
+}
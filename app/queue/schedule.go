@@ -0,0 +1,53 @@
+package queue
+
+// Schedule - Min heap of pending orders, ordered by their execution time, where orders
+// due at the same time keep their order number ordering, to be used with `container/heap`
+type Schedule []*Status
+
+func (s Schedule) Len() int {
+	return len(s)
+}
+
+func (s Schedule) Less(i, j int) bool {
+	a, b := s[i].Order, s[j].Order
+
+	if a.ExecuteTime != b.ExecuteTime {
+		return a.ExecuteTime < b.ExecuteTime
+	}
+
+	if a.OrderNumber != b.OrderNumber {
+		return a.OrderNumber < b.OrderNumber
+	}
+
+	return a.RequestId < b.RequestId
+}
+
+func (s Schedule) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Push - To be invoked only via `heap.Push`
+func (s *Schedule) Push(x interface{}) {
+	*s = append(*s, x.(*Status))
+}
+
+// Pop - To be invoked only via `heap.Pop`
+func (s *Schedule) Pop() interface{} {
+	old := *s
+	n := len(old)
+
+	status := old[n-1]
+	old[n-1] = nil
+	*s = old[:n-1]
+
+	return status
+}
+
+// Head - Order due next, if any
+func (s Schedule) Head() (*Status, bool) {
+	if len(s) == 0 {
+		return nil, false
+	}
+
+	return s[0], true
+}
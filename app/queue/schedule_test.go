@@ -0,0 +1,59 @@
+package queue
+
+import (
+	"container/heap"
+	"testing"
+)
+
+func TestSchedule(t *testing.T) {
+
+	orders := []Order{
+		{RequestId: "b", OrderNumber: 1, ExecuteTime: 20},
+		{RequestId: "a", OrderNumber: 2, ExecuteTime: 10},
+		{RequestId: "b", OrderNumber: 0, ExecuteTime: 10},
+		{RequestId: "a", OrderNumber: 1, ExecuteTime: 10},
+		{RequestId: "a", OrderNumber: 0, ExecuteTime: 30},
+		{RequestId: "b", OrderNumber: 1, ExecuteTime: 10},
+	}
+
+	expected := []Order{
+		{RequestId: "b", OrderNumber: 0, ExecuteTime: 10},
+		{RequestId: "a", OrderNumber: 1, ExecuteTime: 10},
+		{RequestId: "b", OrderNumber: 1, ExecuteTime: 10},
+		{RequestId: "a", OrderNumber: 2, ExecuteTime: 10},
+		{RequestId: "b", OrderNumber: 1, ExecuteTime: 20},
+		{RequestId: "a", OrderNumber: 0, ExecuteTime: 30},
+	}
+
+	schedule := &Schedule{}
+	if _, ok := schedule.Head(); ok {
+		t.Fatal("Head of empty schedule : expected none")
+	}
+
+	for _, order := range orders {
+		heap.Push(schedule, &Status{Order: order})
+	}
+
+	for i, order := range expected {
+
+		head, ok := schedule.Head()
+		if !ok {
+			t.Fatalf("Head %d : expected %v, got none", i, order)
+		}
+
+		status := heap.Pop(schedule).(*Status)
+		if status != head {
+			t.Fatalf("Pop %d : expected head of schedule", i)
+		}
+
+		if status.Order != order {
+			t.Fatalf("Pop %d : expected %v, got %v", i, order, status.Order)
+		}
+
+	}
+
+	if schedule.Len() != 0 {
+		t.Fatalf("expected empty schedule, got %d", schedule.Len())
+	}
+
+}
@@ -0,0 +1,119 @@
+package queue
+
+import "time"
+
+// Session - Virtual replay clock of one subscription request, mapping original
+// trade timestamps onto wall clock time, when those trades are supposed to be published
+//
+// Clock gets anchored when first order of the request is put into replay queue,
+// and it stays frozen while session is paused
+type Session struct {
+	RequestId string
+	Rate      float32
+	IndexTime int64  // original timestamp of the anchored trade, in microseconds
+	StartTime int64  // wall clock time when anchored trade is replayed, in microseconds
+	PausedAt  int64  // wall clock time when session got paused, in microseconds ( 0 if running )
+	Next      uint64 // order number to be used for next order of this session
+	Floor     uint64 // orders numbered below this are stale, being read before last seek
+	Pending   uint64 // orders put into replay queue, which are yet to be due
+	Anchored  bool
+	Cancelled bool
+}
+
+// NewSession - Creates new session clock for given request, which is not yet anchored
+func NewSession(requestId string, rate float32) *Session {
+	return &Session{
+		RequestId: requestId,
+		Rate:      rate,
+	}
+}
+
+// Anchor - Anchors the clock at given trade timestamp, if not done already, so that
+// this trade gets replayed right now ( or right when session gets resumed, if paused )
+func (s *Session) Anchor(timestamp int64) {
+	if s.Anchored {
+		return
+	}
+
+	s.IndexTime = timestamp
+	s.StartTime = time.Now().UnixMicro()
+	if s.Paused() {
+		s.StartTime = s.PausedAt
+	}
+
+	s.Anchored = true
+}
+
+// ExecuteTime - Wall clock time when trade with given original timestamp
+// is to be published, as per current state of the session clock
+func (s *Session) ExecuteTime(timestamp int64) int64 {
+	return s.StartTime + int64(float64(timestamp-s.IndexTime)/float64(s.Rate))
+}
+
+// Position - Original trade timestamp being replayed at this moment, as per
+// current state of the session clock, in microseconds
+func (s *Session) Position() int64 {
+	now := time.Now().UnixMicro()
+	if s.Paused() {
+		now = s.PausedAt
+	}
+
+	return s.IndexTime + int64(float64(now-s.StartTime)*float64(s.Rate))
+}
+
+// Paused - Whether session clock is frozen at this moment or not
+func (s *Session) Paused() bool {
+	return s.PausedAt != 0
+}
+
+// Pause - Freezes session clock, returns false if already paused
+func (s *Session) Pause() bool {
+	if s.Paused() {
+		return false
+	}
+
+	s.PausedAt = time.Now().UnixMicro()
+	return true
+}
+
+// Resume - Unfreezes session clock, shifting it forward by the duration
+// session was paused for. Returns false if session was not paused
+func (s *Session) Resume() bool {
+	if !s.Paused() {
+		return false
+	}
+
+	s.StartTime += time.Now().UnixMicro() - s.PausedAt
+	s.PausedAt = 0
+	return true
+}
+
+// SetRate - Changes replay rate of the session, re-anchoring its clock at the
+// current replay position, so that only trades after this position get affected
+func (s *Session) SetRate(rate float32) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	if s.Anchored {
+		s.IndexTime = s.Position()
+		s.StartTime = time.Now().UnixMicro()
+		if s.Paused() {
+			s.StartTime = s.PausedAt
+		}
+	}
+
+	s.Rate = rate
+	return true
+}
+
+// Seek - Detaches the clock from current replay position, so that it gets re-anchored
+// at the first order numbered from given one onwards, while earlier ones become stale
+func (s *Session) Seek(orderNumber uint64) {
+	s.Anchored = false
+	s.Floor = orderNumber
+
+	if s.Next < orderNumber {
+		s.Next = orderNumber
+	}
+}
@@ -0,0 +1,223 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionExecuteTime(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		rate      float32
+		timestamp int64
+		expected  int64
+	}{
+		{"anchored trade", 1, 1_000, 5_000},
+		{"real time", 1, 3_000, 7_000},
+		{"twice as fast", 2, 3_000, 6_000},
+		{"half as fast", 0.5, 3_000, 9_000},
+		{"before anchored trade", 1, 0, 4_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := &Session{Rate: tt.rate, IndexTime: 1_000, StartTime: 5_000, Anchored: true}
+
+			if got := s.ExecuteTime(tt.timestamp); got != tt.expected {
+				t.Fatalf("ExecuteTime(%d) at rate %v : expected %d, got %d", tt.timestamp, tt.rate, tt.expected, got)
+			}
+
+		})
+	}
+
+}
+
+func TestSessionPosition(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		rate     float32
+		paused   int64 // wall clock time elapsed since anchoring, when session got paused
+		expected int64
+	}{
+		{"right when anchored", 1, 0, 1_000},
+		{"real time", 1, 2_000, 3_000},
+		{"twice as fast", 2, 2_000, 5_000},
+		{"half as fast", 0.5, 2_000, 2_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := &Session{Rate: tt.rate, IndexTime: 1_000, StartTime: 5_000, PausedAt: 5_000 + tt.paused, Anchored: true}
+
+			if got := s.Position(); got != tt.expected {
+				t.Fatalf("Position() : expected %d, got %d", tt.expected, got)
+			}
+
+		})
+	}
+
+}
+
+func TestSessionAnchor(t *testing.T) {
+
+	s := NewSession("req", 1)
+	s.PausedAt = 5_000
+
+	s.Anchor(1_000)
+	if !s.Anchored || s.IndexTime != 1_000 || s.StartTime != 5_000 {
+		t.Fatalf("Anchor while paused : got index %d, start %d", s.IndexTime, s.StartTime)
+	}
+
+	// Already anchored clock is kept as is
+	s.Anchor(2_000)
+	if s.IndexTime != 1_000 || s.StartTime != 5_000 {
+		t.Fatalf("second Anchor : got index %d, start %d", s.IndexTime, s.StartTime)
+	}
+
+}
+
+func TestSessionPauseResume(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		action func(s *Session) bool
+		ok     bool
+		paused bool
+	}{
+		{"pause running", func(s *Session) bool { return s.Pause() }, true, true},
+		{"pause paused", func(s *Session) bool { s.Pause(); return s.Pause() }, false, true},
+		{"resume running", func(s *Session) bool { return s.Resume() }, false, false},
+		{"resume paused", func(s *Session) bool { s.Pause(); return s.Resume() }, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := NewSession("req", 1)
+			s.Anchor(1_000)
+
+			if ok := tt.action(s); ok != tt.ok {
+				t.Fatalf("expected %t, got %t", tt.ok, ok)
+			}
+
+			if s.Paused() != tt.paused {
+				t.Fatalf("expected paused %t, got %t", tt.paused, s.Paused())
+			}
+
+		})
+	}
+
+}
+
+func TestSessionResumeShiftsClock(t *testing.T) {
+
+	s := &Session{Rate: 1, IndexTime: 1_000, StartTime: 5_000, Anchored: true}
+
+	pausedFor := time.Second.Microseconds()
+	s.PausedAt = time.Now().UnixMicro() - pausedFor
+
+	if !s.Resume() {
+		t.Fatal("Resume : expected true")
+	}
+
+	if shift := s.StartTime - 5_000; shift < pausedFor {
+		t.Fatalf("Resume : expected clock shifted by at least %d, got %d", pausedFor, shift)
+	}
+
+	// Trades keep their distance from the anchored one
+	if got := s.ExecuteTime(3_000) - s.ExecuteTime(1_000); got != 2_000 {
+		t.Fatalf("Resume : expected trades 2000 apart, got %d", got)
+	}
+
+}
+func TestSessionSetRate(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		anchored bool
+		rate     float32
+		ok       bool
+		index    int64
+		start    int64
+		execute  int64 // of trade at 3_000_000
+	}{
+		{"faster", true, 2, true, 2_000_000, 2_000_000, 2_500_000},
+		{"slower", true, 0.5, true, 2_000_000, 2_000_000, 4_000_000},
+		{"zero", true, 0, false, 1_000_000, 1_000_000, 3_000_000},
+		{"negative", true, -1, false, 1_000_000, 1_000_000, 3_000_000},
+		{"not anchored", false, 2, true, 1_000_000, 1_000_000, 2_000_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Paused one second of wall clock time past the anchored trade
+			s := &Session{
+				Rate:      1,
+				IndexTime: 1_000_000,
+				StartTime: 1_000_000,
+				PausedAt:  2_000_000,
+				Anchored:  tt.anchored,
+			}
+
+			if ok := s.SetRate(tt.rate); ok != tt.ok {
+				t.Fatalf("SetRate(%v) : expected %t, got %t", tt.rate, tt.ok, ok)
+			}
+
+			if s.IndexTime != tt.index || s.StartTime != tt.start {
+				t.Fatalf("SetRate(%v) : expected index %d, start %d, got index %d, start %d",
+					tt.rate, tt.index, tt.start, s.IndexTime, s.StartTime)
+			}
+
+			if got := s.ExecuteTime(3_000_000); got != tt.execute {
+				t.Fatalf("SetRate(%v) : expected execute time %d, got %d", tt.rate, tt.execute, got)
+			}
+
+		})
+	}
+
+}
+func TestSessionSeek(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		next  uint64
+		seek  uint64
+		floor uint64
+		after uint64
+	}{
+		{"backward", 10, 5, 5, 10},
+		{"current", 10, 10, 10, 10},
+		{"forward", 10, 20, 20, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := &Session{Rate: 1, IndexTime: 1_000, StartTime: 5_000, Next: tt.next, Anchored: true}
+			s.Seek(tt.seek)
+
+			if s.Anchored {
+				t.Fatal("Seek : expected clock to be detached")
+			}
+
+			if s.Floor != tt.floor || s.Next != tt.after {
+				t.Fatalf("Seek(%d) : expected floor %d, next %d, got floor %d, next %d",
+					tt.seek, tt.floor, tt.after, s.Floor, s.Next)
+			}
+
+			// Clock is re-anchored at first order read past seek
+			s.PausedAt = 9_000
+			s.Anchor(7_000)
+			if s.IndexTime != 7_000 || s.StartTime != 9_000 {
+				t.Fatalf("Anchor after Seek : got index %d, start %d", s.IndexTime, s.StartTime)
+			}
+
+		})
+	}
+
+}
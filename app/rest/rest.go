@@ -1,26 +1,27 @@
 package rest
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
-	"path/filepath"
 	"sync"
 
 	"github.com/gin-contrib/cors"
-	"github.com/google/uuid"
 
+	"github.com/denniswon/tcex/app/broker"
 	cfg "github.com/denniswon/tcex/app/config"
+	ds "github.com/denniswon/tcex/app/dataset"
 	ps "github.com/denniswon/tcex/app/pubsub"
 	q "github.com/denniswon/tcex/app/queue"
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/websocket"
 )
 
 // RunHTTPServer - Holds definition for all REST API(s) to be exposed
-func RunHTTPServer(_queue *q.RequestQueue, _redis *redis.Client, tempDir string) {
+func RunHTTPServer(_queue *q.RequestQueue, _broker broker.Broker, catalog *ds.Catalog, resolver *ds.Resolver, uploadDir string) {
 
 	router := gin.Default()
 	router.MaxMultipartMemory = 8 << 20
@@ -32,36 +33,173 @@ func RunHTTPServer(_queue *q.RequestQueue, _redis *redis.Client, tempDir string)
 
 	{
 
-		// For checking the service's syncing status
+		// Uploads input file, to be replayed as dataset, once it's validated
+		//
+		// Files with invalid lines are rejected, unless `strict=false` is passed,
+		// in which case invalid lines are dropped & reported as warnings
 		grp.POST("/upload", func(c *gin.Context) {
 
+			options, strict, ok := uploadOptions(c)
+			ttl, _ok := uploadTTL(c)
+			if !ok || !_ok {
+				c.JSON(http.StatusBadRequest, &ps.SubscriptionResponse{Code: 0, Message: "Bad Payload"})
+				return
+			}
+
 			// single file
-			file, _ := c.FormFile("file")
+			file, err := c.FormFile("file")
+			if err != nil {
+				c.JSON(http.StatusBadRequest, &ps.SubscriptionResponse{Code: 0, Message: "Bad Payload"})
+				return
+			}
 
 			log.Printf("Uploading File: %s (size: %d)\n", file.Filename, file.Size)
 
-			_filepath := filepath.Join(tempDir, file.Filename)
+			// Uploaded file is kept aside, until it's validated
+			upload, err := os.CreateTemp(uploadDir, ".upload-")
+			if err == nil {
+				upload.Close()
+				defer os.Remove(upload.Name())
 
-			header := ps.UploadHeader{
-				ID:       uuid.New().String(),
-				Filepath: _filepath,
-				Size:     file.Size,
+				err = c.SaveUploadedFile(file, upload.Name())
 			}
 
-			// Check if file already exists
-			if _, err := os.Stat(_filepath); err == nil {
+			if err != nil {
+
+				log.Printf("[!] Failed to save uploaded file %s : %s\n", file.Filename, err.Error())
+
+				c.JSON(http.StatusInternalServerError, &ps.SubscriptionResponse{Code: 0, Message: "Failed to save file"})
+
+				return
+
+			}
+
+			c.JSON(addDataset(catalog, uploadDir, file.Filename, upload.Name(), options, strict, ttl))
+
+		})
+
+		// Uploads input file in chunks over websocket, which can be resumed
+		// after reconnecting, then adds it as dataset same as above
+		grp.GET("/ws/upload", func(c *gin.Context) {
 
-				log.Printf("Upload file already exists : %s %d\n", header.Filepath, header.Size)
+			options, strict, ok := uploadOptions(c)
+			ttl, _ok := uploadTTL(c)
+			if !ok || !_ok {
+				c.JSON(http.StatusBadRequest, &ps.SubscriptionResponse{Code: 0, Message: "Bad Payload"})
+				return
+			}
+
+			upgrader := websocket.Upgrader{
+				ReadBufferSize:  1024,
+				WriteBufferSize: 1024,
+				CheckOrigin:     func(r *http.Request) bool { return true },
+			}
 
-				c.JSON(http.StatusOK, header)
+			conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+			if err != nil {
 
+				log.Printf("[!] Failed to upgrade to websocket : %s\n", err.Error())
 				return
 
 			}
+			defer conn.Close()
+
+			if err := HandleUpload(conn, catalog, uploadDir, options, strict, ttl); err != nil {
+				log.Printf("[!] Failed to upload file : %s\n", err.Error())
+			}
+
+		})
+
+		// Datasets uploaded so far, which can be replayed
+		grp.GET("/datasets", func(c *gin.Context) {
+
+			c.JSON(http.StatusOK, catalog.List(c.Query("name")))
+
+		})
+
+		grp.GET("/datasets/:id", func(c *gin.Context) {
+
+			dataset, ok := catalog.Get(c.Param("id"))
+			if !ok {
+				c.JSON(http.StatusNotFound, &ps.SubscriptionResponse{Code: 0, ID: c.Param("id"), Message: "Unknown dataset"})
+				return
+			}
+
+			c.JSON(http.StatusOK, dataset)
+
+		})
+
+		grp.DELETE("/datasets/:id", func(c *gin.Context) {
+
+			dataset, err := catalog.Remove(c.Param("id"))
+			if errors.Is(err, ds.ErrMissing) {
+				c.JSON(http.StatusNotFound, &ps.SubscriptionResponse{Code: 0, ID: c.Param("id"), Message: "Unknown dataset"})
+				return
+			}
+
+			if err != nil {
+
+				log.Printf("[!] Failed to delete dataset %s : %s\n", c.Param("id"), err.Error())
+
+				c.JSON(http.StatusInternalServerError, &ps.SubscriptionResponse{Code: 0, ID: c.Param("id"), Message: "Failed to delete dataset"})
+
+				return
+
+			}
+
+			c.JSON(http.StatusOK, dataset)
+
+		})
+
+		// Same replay as over websocket, delivered as server-sent events, for clients
+		// which can't upgrade to websocket & only need one-way feed
+		grp.GET("/replay/sse", func(c *gin.Context) {
+
+			req, ok := queryRequest(c, resolver)
+			if !ok {
+				return
+			}
+
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			// Asking proxies not to buffer events
+			c.Header("X-Accel-Buffering", "no")
+			c.Status(http.StatusOK)
+			c.Writer.Flush()
+
+			// Stream is kept open after EOF, otherwise clients
+			// would reconnect & start replay over
+			streamReplay(c, _queue, _broker, req, &httpStream{
+				writer:    c.Writer,
+				format:    "data: %s\n\n",
+				ping:      ": ping\n\n",
+				responses: true,
+				eof:       make(chan struct{}),
+			}, false)
+
+		})
+
+		// Same replay as over websocket, delivered as chunked newline delimited JSON,
+		// one line per replayed order or kline, which ends with replay EOF
+		grp.GET("/replay", func(c *gin.Context) {
+
+			req, ok := queryRequest(c, resolver)
+			if !ok {
+				return
+			}
 
-			c.SaveUploadedFile(file, header.Filepath)
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("X-Accel-Buffering", "no")
+			c.Status(http.StatusOK)
+			c.Writer.Flush()
 
-			c.JSON(http.StatusOK, header)
+			streamReplay(c, _queue, _broker, req, &httpStream{
+				writer: c.Writer,
+				format: "%s\n",
+				eof:    make(chan struct{}),
+			}, true)
 
 		})
 
@@ -109,7 +247,7 @@ func RunHTTPServer(_queue *q.RequestQueue, _redis *redis.Client, tempDir string)
 		pubsubManager := ps.SubscriptionManager{
 			Topics:     make(map[string]*ps.SubscriptionRequest),
 			Consumers:  make(map[string]ps.Consumer),
-			Redis:      _redis,
+			Broker:     _broker,
 			Connection: conn,
 			ConnLock:   &connLock,
 			TopicLock:  &topicLock,
@@ -122,20 +260,81 @@ func RunHTTPServer(_queue *q.RequestQueue, _redis *redis.Client, tempDir string)
 			topicLock.Lock()
 			defer topicLock.Unlock()
 
-			for _, v := range pubsubManager.Consumers {
+			for k, v := range pubsubManager.Consumers {
+				_queue.Remove(k)
 				v.Unsubscribe()
 			}
 
 		}()
 
+		// Replay error is to be reported back to client, by cancelling subscription
+		// of failed request, until request is released, when nothing is reported
+		watch := func(requestId string, failed <-chan error) {
+
+			err, ok := <-failed
+			if !ok {
+				return
+			}
+
+			log.Printf("[!] Failed to process order %s : %s\n", requestId, err.Error())
+
+			topicLock.RLock()
+			req, ok := pubsubManager.Topics[requestId]
+			topicLock.RUnlock()
+
+			if ok {
+				pubsubManager.Unsubscribe(req)
+			}
+
+		}
+
+		// Writes response to client's request, over shared network connection,
+		// in format client asked for
+		respond := func(format string, resp *ps.SubscriptionResponse) {
+
+			messageType, data, err := ps.Frame(format, resp)
+			if err != nil {
+				log.Printf("[!] Failed to encode message : %s\n", err.Error())
+				return
+			}
+
+			// -- Critical section of code begins
+			//
+			// Attempting to write to shared network connection
+			connLock.Lock()
+			defer connLock.Unlock()
+
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				log.Printf("[!] Failed to write message : %s\n", err.Error())
+			}
+
+		}
+
 		// Client communication handling logic
 		for {
 
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+
+				log.Printf("[!] Failed to read message : %s\n", err.Error())
+
+				// Nothing more to be read from connection, once it's closed
+				var closeErr *websocket.CloseError
+				var netErr net.Error
+				if errors.As(err, &closeErr) || errors.As(err, &netErr) {
+					return
+				}
+
+				continue
+
+			}
+
+			// Requests can be sent either as JSON or as protobuf
 			var req ps.SubscriptionRequest
 
-			if err := conn.ReadJSON(&req); err != nil {
+			if err := ps.Decode(messageType, data, &req); err != nil {
 
-				log.Printf("[!] Failed to read message : %s\n", err.Error())
+				log.Printf("[!] Failed to decode message : %s\n", err.Error())
 				continue
 
 			}
@@ -145,38 +344,79 @@ func RunHTTPServer(_queue *q.RequestQueue, _redis *redis.Client, tempDir string)
 
 			case "subscribe":
 
-				// Validating incoming request on websocket subscription channel
-				if !req.Validate() {
-					// -- Critical section of code begins
-					//
-					// Attempting to write to shared network connection
-					connLock.Lock()
+				// Filling in defaults of optional fields, i.e. kline granularities
+				req.Generate()
 
-					if err := conn.WriteJSON(&ps.SubscriptionResponse{Code: 0, Message: "Bad Payload"}); err != nil {
-						log.Printf("[!] Failed to write message : %s\n", err.Error())
-					}
+				// Validating incoming request on websocket subscription channel,
+				// whose input file is to be within data directories
+				if !req.Resolve(resolver) || !req.Validate() {
+					respond(req.Format, &ps.SubscriptionResponse{Code: 0, Message: "Bad Payload"})
+					break
+				}
+
+				// Request id is reserved first, so that topic of any other
+				// client's replay is never subscribed to
+				failed, err := _queue.Reserve(&req)
+				if err != nil {
+					respond(req.Format, reserveResponse(&req, err))
+					break
+				}
+
+				// Subscribing before request is started, as first order is published as
+				// soon as request is admitted, which is lost unless someone's listening
+				if err := pubsubManager.Subscribe(&req); err != nil {
+					log.Printf("[!] Failed to subscribe to %s : %s\n", req.ID, err.Error())
+
+					_queue.Remove(req.ID)
+					respond(req.Format, &ps.SubscriptionResponse{Code: 0, ID: req.ID, Message: "Bad Payload"})
+					break
+				}
 
-					connLock.Unlock()
-					// -- ends here
+				if !_queue.Put(req.ID) {
+					pubsubManager.Unsubscribe(&req)
+					respond(req.Format, &ps.SubscriptionResponse{Code: 0, ID: req.ID, Message: "Bad Payload"})
 					break
 				}
 
-				_queue.Put(&req)
-				pubsubManager.Subscribe(&req)
+				go watch(req.ID, failed)
 
 			case "unsubscribe":
-				_queue.Remove(req.ID)
-				pubsubManager.Unsubscribe(&req)
 
-			}
+				// Only replays subscribed to over this connection can be unsubscribed from
+				topicLock.RLock()
+				topic, ok := pubsubManager.Topics[req.ID]
+				topicLock.RUnlock()
 
-		}
+				if !ok {
+					respond(req.Format, &ps.SubscriptionResponse{Code: 0, ID: req.ID, Message: "Unknown subscription"})
+					break
+				}
 
-		for {
+				_queue.Remove(topic.ID)
+				pubsubManager.Unsubscribe(topic)
+
+			case "pause", "resume", "stop", "set_rate", "seek":
 
-			err := <-_queue.Err()
-			log.Printf("[!] Failed to process order %s : %s\n", err.RequestId, err.Err.Error())
-			pubsubManager.Unsubscribe(pubsubManager.Topics[err.RequestId])
+				// Only replays subscribed to over this connection can be controlled
+				topicLock.RLock()
+				topic, ok := pubsubManager.Topics[req.ID]
+				topicLock.RUnlock()
+
+				if !ok {
+					respond(req.Format, &ps.SubscriptionResponse{Code: 0, ID: req.ID, Message: "Unknown subscription"})
+					break
+				}
+
+				applied, message := _queue.Control(&req)
+
+				if !applied {
+					respond(topic.Format, &ps.SubscriptionResponse{Code: 0, ID: req.ID, Message: fmt.Sprintf("Failed to %s `%s`", req.Type, req.ID)})
+					break
+				}
+
+				respond(topic.Format, &ps.SubscriptionResponse{Code: 1, ID: req.ID, Message: message})
+
+			}
 
 		}
 
@@ -184,3 +424,13 @@ func RunHTTPServer(_queue *q.RequestQueue, _redis *redis.Client, tempDir string)
 
 	router.Run(fmt.Sprintf(":%s", cfg.GetPort()))
 }
+
+// reserveResponse - Tells client why its subscription request couldn't be reserved
+func reserveResponse(req *ps.SubscriptionRequest, err error) *ps.SubscriptionResponse {
+
+	if errors.Is(err, q.ErrDuplicate) {
+		return &ps.SubscriptionResponse{Code: 0, ID: req.ID, Message: "Duplicate subscription"}
+	}
+
+	return &ps.SubscriptionResponse{Code: 0, ID: req.ID, Message: "Bad Payload"}
+}
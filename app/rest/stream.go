@@ -0,0 +1,293 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/denniswon/tcex/app/broker"
+	d "github.com/denniswon/tcex/app/data"
+	ds "github.com/denniswon/tcex/app/dataset"
+	ps "github.com/denniswon/tcex/app/pubsub"
+	q "github.com/denniswon/tcex/app/queue"
+)
+
+// httpStream - Streamed http response i.e. server-sent events or newline delimited
+// JSON, which pubsub consumers can deliver replayed data over, same as they do over
+// websocket connection
+//
+// To be written to only while holding connection lock
+type httpStream struct {
+	writer    gin.ResponseWriter
+	format    string        // how each JSON message is written, as `fmt` format
+	ping      string        // sent when nothing else was, to keep proxies from closing idle stream
+	responses bool          // whether subscription responses are to be written too, along with replayed data
+	eof       chan struct{} // closed once replay EOF is written
+	closed    bool
+}
+
+// WriteMessage - Writes JSON message as one event/ line, as it is, where
+// message type is ignored, given http streams carry JSON only
+func (h *httpStream) WriteMessage(messageType int, data []byte) error {
+
+	if h.closed {
+		return errors.New("http stream closed")
+	}
+
+	if _, err := fmt.Fprintf(h.writer, h.format, data); err != nil {
+		return err
+	}
+
+	h.writer.Flush()
+	return nil
+}
+
+// WriteData - Writes data JSON encoded, unless it's subscription response, which
+// isn't asked for, noting whether replay is over, once its EOF is written
+func (h *httpStream) WriteData(data interface{}) error {
+
+	if _, ok := data.(*ps.SubscriptionResponse); ok && !h.responses {
+		return nil
+	}
+
+	_data, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if err := h.WriteMessage(websocket.TextMessage, _data); err != nil {
+		return err
+	}
+
+	if _, ok := data.(*d.EOF); ok {
+		select {
+		case <-h.eof:
+		default:
+			close(h.eof)
+		}
+	}
+
+	return nil
+}
+
+// fail - Writes error response as the last event/ line, even if subscription responses are
+// not written otherwise, as status is already sent, so that client can tell failed replay
+// from an empty one
+func (h *httpStream) fail(resp *ps.SubscriptionResponse) error {
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	return h.WriteMessage(websocket.TextMessage, data)
+}
+
+// keepAlive - Writes ping, if stream has one
+func (h *httpStream) keepAlive() error {
+
+	if h.closed {
+		return errors.New("http stream closed")
+	}
+
+	if h.ping == "" {
+		return nil
+	}
+
+	if _, err := fmt.Fprint(h.writer, h.ping); err != nil {
+		return err
+	}
+
+	h.writer.Flush()
+	return nil
+}
+
+// queryRequest - Reads subscription request from query parameters, named same as
+// JSON fields of subscription request, responding with `400` if it's not valid
+func queryRequest(c *gin.Context, resolver *ds.Resolver) (*ps.SubscriptionRequest, bool) {
+
+	var req ps.SubscriptionRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, &ps.SubscriptionResponse{Code: 0, Message: "Bad Payload"})
+		return nil, false
+	}
+
+	if loop, ok := c.GetQuery("loop"); ok {
+		if err := req.Loop.UnmarshalJSON([]byte(loop)); err != nil {
+			c.JSON(http.StatusBadRequest, &ps.SubscriptionResponse{Code: 0, Message: "Bad Payload"})
+			return nil, false
+		}
+	}
+
+	if value, ok := c.GetQuery("columns"); ok {
+		columns, ok := parseColumns(value)
+		if !ok {
+			c.JSON(http.StatusBadRequest, &ps.SubscriptionResponse{Code: 0, Message: "Bad Payload"})
+			return nil, false
+		}
+
+		req.Columns = columns
+	}
+
+	// http streams carry JSON only
+	req.Type = "subscribe"
+	req.Format = "json"
+	req.Generate()
+
+	if !req.Resolve(resolver) || !req.Validate() {
+		c.JSON(http.StatusBadRequest, &ps.SubscriptionResponse{Code: 0, Message: "Bad Payload"})
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// parseColumns - Parses column mapping of csv & tsv input files, given
+// as comma separated `field:column` pairs in query string
+func parseColumns(value string) (map[string]string, bool) {
+
+	columns := make(map[string]string)
+
+	for _, pair := range strings.Split(value, ",") {
+
+		field, column, ok := strings.Cut(pair, ":")
+		if !ok || field == "" || column == "" {
+			return nil, false
+		}
+
+		columns[strings.TrimSpace(field)] = strings.TrimSpace(column)
+
+	}
+
+	return columns, true
+}
+
+// streamReplay - Replays given subscription request over http stream, until client goes
+// away or replay fails, or until replay EOF is written, if asked to
+func streamReplay(c *gin.Context, _queue *q.RequestQueue, _broker broker.Broker, req *ps.SubscriptionRequest, stream *httpStream, untilEOF bool) {
+
+	connLock := sync.Mutex{}
+	topicLock := sync.RWMutex{}
+
+	pubsubManager := ps.SubscriptionManager{
+		Topics:     make(map[string]*ps.SubscriptionRequest),
+		Consumers:  make(map[string]ps.Consumer),
+		Broker:     _broker,
+		Connection: stream,
+		ConnLock:   &connLock,
+		TopicLock:  &topicLock,
+	}
+
+	// Reports failure of replay to client, before stream gets closed
+	fail := func(resp *ps.SubscriptionResponse) {
+
+		connLock.Lock()
+		err := stream.fail(resp)
+		connLock.Unlock()
+
+		if err != nil {
+			log.Printf("[!] Failed to write error to http stream of request %s : %s\n", req.ID, err.Error())
+		}
+
+	}
+
+	// Request id is reserved first, so that topic of any other
+	// client's replay is never subscribed to
+	failed, err := _queue.Reserve(req)
+	if err != nil {
+		fail(reserveResponse(req, err))
+		return
+	}
+
+	// Unsubscribing when returning, after which nothing can be written to
+	// the stream anymore, given response writer is done with once handler returns
+	defer func() {
+
+		topicLock.Lock()
+		for k, v := range pubsubManager.Consumers {
+			_queue.Remove(k)
+			v.Unsubscribe()
+		}
+		topicLock.Unlock()
+
+		connLock.Lock()
+		stream.closed = true
+		connLock.Unlock()
+
+		log.Printf("[] Closing http stream of request %s\n", req.ID)
+
+	}()
+
+	// Subscribing before request is started, not to miss orders
+	// published right after request is admitted
+	if err := pubsubManager.Subscribe(req); err != nil {
+		log.Printf("[!] Failed to subscribe to %s : %s\n", req.ID, err.Error())
+
+		_queue.Remove(req.ID)
+		fail(&ps.SubscriptionResponse{Code: 0, ID: req.ID, Message: "Bad Payload"})
+		return
+	}
+
+	if !_queue.Put(req.ID) {
+		fail(&ps.SubscriptionResponse{Code: 0, ID: req.ID, Message: "Bad Payload"})
+		return
+	}
+
+	// Stream is to be kept open after EOF, unless asked otherwise
+	var eof chan struct{}
+	if untilEOF {
+		eof = stream.eof
+	}
+
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+
+		select {
+
+		case <-c.Request.Context().Done():
+			return
+
+		case <-eof:
+			return
+
+		case <-ticker.C:
+
+			connLock.Lock()
+			err := stream.keepAlive()
+			connLock.Unlock()
+
+			if err != nil {
+				log.Printf("[!] Failed to ping http stream of request %s : %s\n", req.ID, err.Error())
+				return
+			}
+
+		case err, ok := <-failed:
+
+			// Request got released without failing, nothing more to be reported
+			if !ok {
+				failed = nil
+				break
+			}
+
+			log.Printf("[!] Failed to process order %s : %s\n", req.ID, err.Error())
+
+			fail(&ps.SubscriptionResponse{Code: 0, ID: req.ID, Message: fmt.Sprintf("Failed to replay `%s` : %s", req.ID, err.Error())})
+
+			pubsubManager.Unsubscribe(req)
+			return
+
+		}
+
+	}
+}
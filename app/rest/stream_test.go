@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	d "github.com/denniswon/tcex/app/data"
+	ps "github.com/denniswon/tcex/app/pubsub"
+)
+
+func TestHTTPStreamWriteData(t *testing.T) {
+
+	// Data which merely looks like EOF doesn't end stream
+	data := []interface{}{
+		&ps.SubscriptionResponse{Code: 1, ID: "req", Message: "Subscribed to `req`"},
+		&d.Order{Price: "request_id", Timestamp: 1},
+		&d.Loop{RequestID: "req", Loop: 1},
+		&d.EOF{RequestID: "req"},
+	}
+
+	tests := []struct {
+		name      string
+		responses bool
+		expected  []string
+	}{
+		{
+			name:      "without responses",
+			responses: false,
+			expected:  []string{`"price":"request_id"`, `"loop":1`, `{"request_id":"req"}`},
+		},
+		{
+			name:      "with responses",
+			responses: true,
+			expected:  []string{`"code":1`, `"price":"request_id"`, `"loop":1`, `{"request_id":"req"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+
+			stream := &httpStream{writer: c.Writer, format: "%s\n", responses: tt.responses, eof: make(chan struct{})}
+
+			for i, _data := range data {
+
+				select {
+				case <-stream.eof:
+					t.Fatalf("stream ended before %T", _data)
+				default:
+				}
+
+				if err := stream.WriteData(_data); err != nil {
+					t.Fatalf("WriteData %d : %s", i, err)
+				}
+
+			}
+
+			select {
+			case <-stream.eof:
+			default:
+				t.Fatal("expected stream to end with EOF")
+			}
+
+			lines := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n"), "\n")
+			if len(lines) != len(tt.expected) {
+				t.Fatalf("expected %d lines, got %q", len(tt.expected), lines)
+			}
+
+			for i, line := range lines {
+				if !strings.Contains(line, tt.expected[i]) {
+					t.Fatalf("line %d : expected %s, got %s", i, tt.expected[i], line)
+				}
+			}
+
+			// Nothing is written once stream is closed
+			stream.closed = true
+			if err := stream.WriteData(data[3]); err == nil {
+				t.Fatal("expected closed stream not to be written to")
+			}
+
+		})
+	}
+
+}
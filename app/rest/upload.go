@@ -1,105 +1,385 @@
 package rest
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
 
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 
+	cfg "github.com/denniswon/tcex/app/config"
+	ds "github.com/denniswon/tcex/app/dataset"
 	ps "github.com/denniswon/tcex/app/pubsub"
+	"github.com/denniswon/tcex/app/source"
 )
 
+// MaxChunkSize - Max size of file chunk, sent in one websocket message
+const MaxChunkSize = 4 << 20
+
+// uploads - Ids of chunked uploads being received, so that
+// same upload is never resumed over two connections at once
+var uploads sync.Map
+
 type wsConn struct {
 	conn *websocket.Conn
 }
 
-func (ws *wsConn) sendUploadHeader(header *ps.UploadHeader) {
-	ws.conn.WriteMessage(websocket.TextMessage, []byte(header.String()))
+// sendStatus - Lets client know how far upload has got
+func (ws *wsConn) sendStatus(status *ps.UploadStatus) error {
+
+	if status.Size > 0 {
+		status.Progress = float64(status.Offset) / float64(status.Size) * 100
+	}
+
+	return ws.conn.WriteJSON(status)
+
+}
+
+// requestNextBlock - Asks for the chunk starting at given offset, reporting progress,
+// or for the same chunk once again, if it got corrupted
+func (ws *wsConn) requestNextBlock(header *ps.UploadHeader, offset int64, retry bool) error {
+
+	status := &ps.UploadStatus{Type: "next", ID: header.ID, Offset: offset, Size: header.Size}
+	if retry {
+		status.Type = "retry"
+	}
+
+	return ws.sendStatus(status)
+
 }
 
-func (ws *wsConn) requestNextBlock() {
-	ws.conn.WriteMessage(websocket.TextMessage, []byte("NEXT"))
+// sendError - Lets client know upload failed, which can't be resumed
+func (ws *wsConn) sendError(header *ps.UploadHeader, offset int64, message string) {
+	ws.sendStatus(&ps.UploadStatus{Type: "error", ID: header.ID, Offset: offset, Size: header.Size, Message: message})
 }
 
-// HandleUpload - Handles file upload from client to server and sends the result response back to client
-// returns error if any during upload
-func HandleUpload(conn *websocket.Conn, header *ps.UploadHeader, tempDir string) error {
+// HandleUpload - Receives file from client in chunks, each prefixed with sha256 digest of its
+// data, resuming from where it was left off, if client reconnects with id of the upload. Once
+// whole file is received & matches its sha256, it's added as dataset & result is sent back
+//
+// Partially received file is kept in upload directory till upload is resumed, unless client sends `CANCEL`
+func HandleUpload(conn *websocket.Conn, catalog *ds.Catalog, uploadDir string, options source.Options, strict bool, ttl int64) error {
 
 	ws := &wsConn{conn: conn}
-	var err error
 
-	_filepath := filepath.Join(tempDir, header.Filepath)
+	conn.SetReadLimit(MaxChunkSize + sha256.Size)
 
-	header.Filepath = _filepath
-	header.Generate() // assign a new request id
+	// First message is the header of file being uploaded
+	var header ps.UploadHeader
 
-	// Check if file already exists
-	if _, err = os.Stat(_filepath); err == nil {
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
 
-		log.Printf("Upload file already exists : %s %d\n", header.Filepath, header.Size)
+	if err := json.Unmarshal(message, &header); err != nil {
+		ws.sendError(&header, 0, "Bad Payload")
+		return err
+	}
+
+	filename := filepath.Base(header.Filepath)
+	checksum, err := hex.DecodeString(header.SHA256)
+
+	if filename == "." || filename == ".." || filename == string(filepath.Separator) || header.Size <= 0 || err != nil || len(checksum) != sha256.Size {
+		ws.sendError(&header, 0, "Bad Payload")
+		return errors.New("bad upload header")
+	}
 
-		ws.sendUploadHeader(header)
+	// Only ids handed out by server can be resumed
+	if header.ID != "" {
+		if _, err := uuid.Parse(header.ID); err != nil {
+			ws.sendError(&header, 0, "Bad Payload")
+			return err
+		}
+	}
 
-		return nil
+	header.Generate() // assign a new upload id, unless resuming
 
+	if _, loaded := uploads.LoadOrStore(header.ID, struct{}{}); loaded {
+		ws.sendError(&header, 0, "Upload already in progress")
+		return fmt.Errorf("upload %s already in progress", header.ID)
 	}
+	defer uploads.Delete(header.ID)
 
-	// Create temp file to save file.
-	var namedFile *os.File
-	if namedFile, err = os.Create(header.Filepath); err != nil {
+	// Chunks received so far, which are all verified
+	partial := filepath.Join(uploadDir, ".partial-"+header.ID)
+
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		ws.sendError(&header, 0, "Failed to save file")
 		return err
 	}
-	defer func() {
-		namedFile.Close()
-	}()
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		ws.sendError(&header, 0, "Failed to save file")
+		return err
+	}
+
+	offset := info.Size()
+
+	// Different file might have been uploaded with same id before
+	if offset > header.Size {
+
+		if err := file.Truncate(0); err != nil {
+			ws.sendError(&header, 0, "Failed to save file")
+			return err
+		}
+
+		offset = 0
+
+	}
+
+	log.Printf("Uploading File: %s (size: %d, offset: %d)\n", filename, header.Size, offset)
+
+	retry := false
 
 	// Read file blocks until all bytes are received.
-	var bytesRead int64 = 0
+	for offset < header.Size {
 
-	for {
+		if err := ws.requestNextBlock(&header, offset, retry); err != nil {
+			return err
+		}
 
-		mt, message, _err := ws.conn.ReadMessage()
+		retry = false
 
-		if _err != nil {
-			err = _err
-			break
+		mt, message, err := conn.ReadMessage()
+		if err != nil {
+			// Partial file is kept, for resuming upload
+			return err
 		}
 
 		if mt != websocket.BinaryMessage {
 
 			if mt == websocket.TextMessage && string(message) == "CANCEL" {
-				err = errors.New("upload cancelled")
-				break
+				file.Close()
+				os.Remove(partial)
+
+				ws.sendError(&header, offset, "Upload cancelled")
+				return errors.New("upload cancelled")
 			}
 
-			err = errors.New("invalid file block received")
-			break
+			ws.sendError(&header, offset, "Invalid file block received")
+			return errors.New("invalid file block received")
+
+		}
+
+		if len(message) <= sha256.Size {
+			ws.sendError(&header, offset, "Invalid file block received")
+			return errors.New("invalid file block received")
 		}
 
-		namedFile.Write(message)
+		digest, block := message[:sha256.Size], message[sha256.Size:]
+
+		// Corrupted chunk is to be sent again, from same offset
+		if sum := sha256.Sum256(block); !bytes.Equal(sum[:], digest) {
 
-		bytesRead += int64(len(message))
+			log.Printf("[!] Checksum mismatch of file block at %d : %s\n", offset, header.ID)
+
+			retry = true
+			continue
+
+		}
 
-		if bytesRead == header.Size {
+		if offset+int64(len(block)) > header.Size {
+			ws.sendError(&header, offset, "File larger than its size")
+			return errors.New("file larger than its size")
+		}
 
-			namedFile.Close()
-			break
+		if _, err := file.Write(block); err != nil {
+			ws.sendError(&header, offset, "Failed to save file")
+			return err
 		}
 
-		ws.requestNextBlock()
+		offset += int64(len(block))
+
+	}
+
+	if err := file.Close(); err != nil {
+		ws.sendError(&header, offset, "Failed to save file")
+		return err
 	}
 
+	// Whole file is checked once again, as it might have been
+	// received over multiple connections
+	sum, err := hashFile(partial)
 	if err != nil {
-		os.Remove(namedFile.Name())
+		ws.sendError(&header, offset, "Failed to save file")
 		return err
-	} else {
+	}
+
+	if !bytes.Equal(sum, checksum) {
+		os.Remove(partial)
+
+		ws.sendError(&header, 0, "Checksum mismatch")
+		return fmt.Errorf("checksum mismatch of uploaded file %s", header.ID)
+	}
+
+	defer os.Remove(partial)
+
+	log.Printf("Upload finished with request id %s: %s %d\n", header.ID, filename, header.Size)
+
+	code, resp := addDataset(catalog, uploadDir, filename, partial, options, strict, ttl)
+	if code != http.StatusOK {
+
+		status := &ps.UploadStatus{Type: "error", ID: header.ID, Offset: offset, Size: header.Size}
+
+		switch resp := resp.(type) {
+		case *ds.Report:
+			status.Message = "Rejected file"
+			status.Report = resp
+		case *ps.SubscriptionResponse:
+			status.Message = resp.Message
+		}
 
-		log.Printf("Upload finished with request id %s: %s %d\n", header.ID, header.Filepath, header.Size)
+		return ws.sendStatus(status)
+
+	}
+
+	return ws.sendStatus(&ps.UploadStatus{Type: "done", ID: header.ID, Offset: offset, Size: header.Size, Header: resp.(*ps.UploadHeader)})
+}
+
+// uploadOptions - Reads whether uploaded file is validated strictly & how it's to be
+// decoded from query parameters, where anything not given is detected or defaulted
+func uploadOptions(c *gin.Context) (source.Options, bool, bool) {
+
+	options := source.Options{
+		Format: c.Query("file_format"),
+		Unit:   c.Query("timestamp_unit"),
+	}
+
+	strict, err := strconv.ParseBool(c.DefaultQuery("strict", "true"))
+	if err != nil {
+		return options, false, false
+	}
+
+	if value, ok := c.GetQuery("columns"); ok {
+		columns, ok := parseColumns(value)
+		if !ok {
+			return options, false, false
+		}
+
+		options.Columns = columns
+	}
+
+	if err := options.Check(); err != nil {
+		return options, false, false
+	}
+
+	return options, strict, true
+}
+
+// uploadTTL - Reads for how long ( in seconds ) uploaded dataset is to be kept since
+// it was last used from query parameter, defaulting to the configured one
+func uploadTTL(c *gin.Context) (int64, bool) {
+
+	value, ok := c.GetQuery("ttl")
+	if !ok {
+		return int64(cfg.GetDatasetTTL()), true
+	}
+
+	ttl, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ttl < 0 {
+		return 0, false
+	}
+
+	return ttl, true
+}
+
+// addDataset - Validates uploaded file & adds it as dataset, stored by sha256 of its content,
+// returning status code along with the response to be sent back to client. Uploaded file is
+// stored as is, while the one with invalid lines dropped is stored & hashed instead, when
+// it's accepted without being strict
+func addDataset(catalog *ds.Catalog, uploadDir string, filename string, upload string, options source.Options, strict bool, ttl int64) (int, interface{}) {
+
+	// Valid lines are kept aside, until they're stored by their content
+	validated, err := os.CreateTemp(uploadDir, ".validated-")
+	if err != nil {
+
+		log.Printf("[!] Failed to save uploaded file %s : %s\n", filename, err.Error())
+
+		return http.StatusInternalServerError, &ps.SubscriptionResponse{Code: 0, Message: "Failed to save file"}
+
+	}
+
+	validated.Close()
+	defer os.Remove(validated.Name())
+
+	report, err := ds.Validate(upload, validated.Name(), filename, options, strict)
+	if err != nil {
+
+		log.Printf("[!] Failed to validate uploaded file %s : %s\n", filename, err.Error())
+
+		return http.StatusInternalServerError, &ps.SubscriptionResponse{Code: 0, Message: "Failed to validate file"}
+
+	}
+
+	if !report.Accepted {
+
+		log.Printf("[!] Rejected uploaded file %s : %d invalid line(s)\n", filename, report.Invalid)
+
+		return http.StatusUnprocessableEntity, report
+
+	}
+
+	// File is stored as it was uploaded, so that its sha256 is the one client
+	// knows of, unless invalid lines got dropped from it
+	stored := upload
+	if report.Invalid > 0 {
+		stored = validated.Name()
+	}
+
+	dataset, duplicate, err := catalog.Add(filename, stored, options, ttl)
+	if err != nil {
+
+		log.Printf("[!] Failed to add dataset %s : %s\n", filename, err.Error())
+
+		return http.StatusInternalServerError, &ps.SubscriptionResponse{Code: 0, Message: "Failed to add dataset"}
+
+	}
+
+	if duplicate {
+		log.Printf("Upload file already exists : %s %s\n", filename, dataset.ID)
+	}
+
+	// Stored file is referred to relative to upload directory, as
+	// input files of replays can't be given as absolute paths
+	return http.StatusOK, &ps.UploadHeader{
+		ID:        dataset.ID,
+		Filepath:  filepath.Base(dataset.Filepath),
+		Size:      dataset.Size,
+		SHA256:    dataset.SHA256,
+		Duplicate: duplicate,
+		Report:    report,
+	}
+}
+
+// hashFile - Computes sha256 digest of whole file
+func hashFile(name string) ([]byte, error) {
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
-		ws.sendUploadHeader(header)
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return hash.Sum(nil), nil
 }
@@ -0,0 +1,377 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	ds "github.com/denniswon/tcex/app/dataset"
+	ps "github.com/denniswon/tcex/app/pubsub"
+	"github.com/denniswon/tcex/app/source"
+)
+
+const uploaded = `{"price":"1.5","quantity":10,"aggressor":"bid","timestamp":1000}
+{"price":"2.5","quantity":5,"aggressor":"ask","timestamp":2000}
+{"price":"3.5","quantity":1,"aggressor":"bid","timestamp":3000}
+`
+
+// chunkSize - Size of chunks uploaded by tests, so that file takes a few of them
+const chunkSize = 100
+
+// uploadServer - Serves chunked uploads into its own upload directory, reporting
+// outcome of each connection, once it's handled
+type uploadServer struct {
+	*httptest.Server
+	catalog *ds.Catalog
+	dir     string
+	handled chan error
+}
+
+func newUploadServer(t *testing.T) *uploadServer {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	catalog, err := ds.NewCatalog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &uploadServer{catalog: catalog, dir: dir, handled: make(chan error, 8)}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		upgrader := websocket.Upgrader{}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			s.handled <- err
+			return
+		}
+		defer conn.Close()
+
+		s.handled <- HandleUpload(conn, catalog, dir, source.Options{}, true, 0)
+
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// dial - Connects to upload server & sends upload header
+func (s *uploadServer) dial(t *testing.T, header *ps.UploadHeader) *websocket.Conn {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if err := conn.WriteJSON(header); err != nil {
+		t.Fatal(err)
+	}
+
+	return conn
+}
+
+// wait - Waits for upload connection to be handled, returning its outcome
+func (s *uploadServer) wait(t *testing.T) error {
+	t.Helper()
+
+	select {
+	case err := <-s.handled:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("upload not handled in time")
+	}
+
+	return nil
+}
+
+// header - Upload header of given content, named as given
+func header(name string, content string) *ps.UploadHeader {
+	sum := sha256.Sum256([]byte(content))
+	return &ps.UploadHeader{Filepath: name, Size: int64(len(content)), SHA256: hex.EncodeToString(sum[:])}
+}
+
+// chunk - Chunk of content at given offset, prefixed with its sha256 digest
+func chunk(content string, offset int64) []byte {
+
+	end := offset + chunkSize
+	if end > int64(len(content)) {
+		end = int64(len(content))
+	}
+
+	block := []byte(content[offset:end])
+	sum := sha256.Sum256(block)
+
+	return append(sum[:], block...)
+}
+
+// status - Reads next upload status sent by server
+func status(t *testing.T, conn *websocket.Conn) *ps.UploadStatus {
+	t.Helper()
+
+	var _status ps.UploadStatus
+	if err := conn.ReadJSON(&_status); err != nil {
+		t.Fatalf("expected upload status, got %s", err)
+	}
+
+	return &_status
+}
+
+// send - Sends chunks asked for, until upload is over, returning last status along with
+// statuses received before it, formatted as `type offset progress`
+func send(t *testing.T, conn *websocket.Conn, content string, corrupt map[int64]bool) (*ps.UploadStatus, []string) {
+	t.Helper()
+
+	var statuses []string
+
+	for {
+
+		_status := status(t, conn)
+		if _status.Type != "next" && _status.Type != "retry" {
+			return _status, statuses
+		}
+
+		statuses = append(statuses, fmt.Sprintf("%s %d %.0f", _status.Type, _status.Offset, _status.Progress))
+
+		data := chunk(content, _status.Offset)
+
+		// Chunk is corrupted only once, so that it's intact when sent again
+		if corrupt[_status.Offset] {
+			delete(corrupt, _status.Offset)
+			data[len(data)-1] ^= 0xff
+		}
+
+		if err := conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+			t.Fatal(err)
+		}
+
+	}
+}
+
+// partials - Partially received files left in upload directory
+func partials(t *testing.T, dir string) []string {
+	t.Helper()
+
+	matches, err := filepath.Glob(filepath.Join(dir, ".partial-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return matches
+}
+
+func TestHandleUpload(t *testing.T) {
+
+	sum := sha256.Sum256([]byte(uploaded))
+	id := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		corrupt  map[int64]bool
+		statuses []string
+	}{
+		{
+			name:     "in chunks",
+			corrupt:  map[int64]bool{},
+			statuses: []string{"next 0 0", "next 100 52"},
+		},
+		{
+			// Corrupted chunk is asked for once again, from same offset
+			name:     "chunk checksum mismatch",
+			corrupt:  map[int64]bool{100: true},
+			statuses: []string{"next 0 0", "next 100 52", "retry 100 52"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := newUploadServer(t)
+			conn := s.dial(t, header("trades.jsonl", uploaded))
+
+			last, statuses := send(t, conn, uploaded, tt.corrupt)
+
+			if strings.Join(statuses, ", ") != strings.Join(tt.statuses, ", ") {
+				t.Fatalf("expected statuses %q, got %q", tt.statuses, statuses)
+			}
+
+			if last.Type != "done" || last.Progress != 100 || last.Header == nil || last.Header.ID != id {
+				t.Fatalf("expected upload done as dataset %s, got %+v", id, last)
+			}
+
+			if err := s.wait(t); err != nil {
+				t.Fatalf("HandleUpload : %s", err)
+			}
+
+			if _, ok := s.catalog.Get(id); !ok {
+				t.Fatalf("expected dataset %s to be added", id)
+			}
+
+			if left := partials(t, s.dir); len(left) != 0 {
+				t.Fatalf("expected partial file to be removed, got %v", left)
+			}
+
+		})
+	}
+
+}
+
+func TestHandleUploadResume(t *testing.T) {
+
+	s := newUploadServer(t)
+
+	// First chunk is received, before connection drops
+	first := s.dial(t, header("trades.jsonl", uploaded))
+
+	_status := status(t, first)
+	if _status.Type != "next" || _status.Offset != 0 {
+		t.Fatalf("expected first chunk to be asked for, got %+v", _status)
+	}
+
+	if err := first.WriteMessage(websocket.BinaryMessage, chunk(uploaded, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _status := status(t, first); _status.Offset != chunkSize {
+		t.Fatalf("expected second chunk to be asked for, got %+v", _status)
+	}
+
+	first.Close()
+
+	if err := s.wait(t); err == nil {
+		t.Fatal("HandleUpload : expected dropped connection to fail upload")
+	}
+
+	if left := partials(t, s.dir); len(left) != 1 {
+		t.Fatalf("expected partial file to be kept, got %v", left)
+	}
+
+	// Upload is resumed with its id, from where it was left off
+	resumed := header("trades.jsonl", uploaded)
+	resumed.ID = _status.ID
+
+	second := s.dial(t, resumed)
+
+	last, statuses := send(t, second, uploaded, map[int64]bool{})
+
+	if strings.Join(statuses, ", ") != "next 100 52" {
+		t.Fatalf("expected upload resumed at %d, got %q", chunkSize, statuses)
+	}
+
+	if last.Type != "done" || last.ID != _status.ID {
+		t.Fatalf("expected upload %s done, got %+v", _status.ID, last)
+	}
+
+	if err := s.wait(t); err != nil {
+		t.Fatalf("HandleUpload : %s", err)
+	}
+
+}
+
+func TestHandleUploadChecksumMismatch(t *testing.T) {
+
+	s := newUploadServer(t)
+
+	// Header announces sha256 of some other content of same size
+	_header := header("trades.jsonl", uploaded)
+	other := header("trades.jsonl", strings.Replace(uploaded, "1.5", "9.5", 1))
+	_header.SHA256 = other.SHA256
+
+	conn := s.dial(t, _header)
+
+	last, _ := send(t, conn, uploaded, map[int64]bool{})
+
+	if last.Type != "error" || last.Message != "Checksum mismatch" || last.Offset != 0 {
+		t.Fatalf("expected checksum mismatch, got %+v", last)
+	}
+
+	if err := s.wait(t); err == nil {
+		t.Fatal("HandleUpload : expected checksum mismatch to fail upload")
+	}
+
+	// Received file can't be resumed, as it's not the one announced
+	if left := partials(t, s.dir); len(left) != 0 {
+		t.Fatalf("expected partial file to be removed, got %v", left)
+	}
+
+	if datasets := s.catalog.List(""); len(datasets) != 0 {
+		t.Fatalf("expected no dataset, got %v", datasets)
+	}
+
+}
+
+func TestHandleUploadCancel(t *testing.T) {
+
+	s := newUploadServer(t)
+	conn := s.dial(t, header("trades.jsonl", uploaded))
+
+	status(t, conn)
+	if err := conn.WriteMessage(websocket.BinaryMessage, chunk(uploaded, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	status(t, conn)
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("CANCEL")); err != nil {
+		t.Fatal(err)
+	}
+
+	if last := status(t, conn); last.Type != "error" || last.Message != "Upload cancelled" {
+		t.Fatalf("expected upload cancelled, got %+v", last)
+	}
+
+	if err := s.wait(t); err == nil {
+		t.Fatal("HandleUpload : expected cancelled upload to fail")
+	}
+
+	if left := partials(t, s.dir); len(left) != 0 {
+		t.Fatalf("expected partial file to be removed, got %v", left)
+	}
+
+}
+
+func TestHandleUploadBadHeader(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		header *ps.UploadHeader
+	}{
+		{"no size", &ps.UploadHeader{Filepath: "trades.jsonl", SHA256: header("", uploaded).SHA256}},
+		{"bad checksum", &ps.UploadHeader{Filepath: "trades.jsonl", Size: 10, SHA256: "abc"}},
+		{"no name", &ps.UploadHeader{Filepath: "/", Size: 10, SHA256: header("", uploaded).SHA256}},
+		{"id not handed out", &ps.UploadHeader{ID: "../x", Filepath: "trades.jsonl", Size: 10, SHA256: header("", uploaded).SHA256}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := newUploadServer(t)
+			conn := s.dial(t, tt.header)
+
+			if last := status(t, conn); last.Type != "error" || last.Message != "Bad Payload" {
+				t.Fatalf("expected bad payload, got %+v", last)
+			}
+
+			if err := s.wait(t); err == nil {
+				t.Fatal("HandleUpload : expected bad header to fail upload")
+			}
+
+			if left := partials(t, s.dir); len(left) != 0 {
+				t.Fatalf("expected nothing to be received, got %v", left)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,242 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/denniswon/tcex/app/broker"
+	cfg "github.com/denniswon/tcex/app/config"
+	ds "github.com/denniswon/tcex/app/dataset"
+	"github.com/denniswon/tcex/app/pb"
+	ps "github.com/denniswon/tcex/app/pubsub"
+	q "github.com/denniswon/tcex/app/queue"
+)
+
+// server - Implements `Replayer` gRPC service on top of request queue,
+// same as websocket endpoint does
+type server struct {
+	pb.UnimplementedReplayerServer
+
+	queue    *q.RequestQueue
+	broker   broker.Broker
+	resolver *ds.Resolver
+}
+
+// RunGRPCServer - Serves replays over gRPC, on its own port, if one is configured
+func RunGRPCServer(_queue *q.RequestQueue, _broker broker.Broker, resolver *ds.Resolver) {
+
+	port := cfg.GetGRPCPort()
+	if port == "" {
+		log.Printf("[!] gRPC port not configured, not starting gRPC server\n")
+		return
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		log.Printf("[!] Failed to listen for gRPC connections : %s\n", err.Error())
+		return
+	}
+
+	// Panic while handling one call is not supposed to take down the whole server
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(recoverUnary),
+		grpc.StreamInterceptor(recoverStream),
+	)
+	pb.RegisterReplayerServer(grpcServer, &server{queue: _queue, broker: _broker, resolver: resolver})
+
+	log.Printf("[+] Serving gRPC on :%s\n", port)
+
+	if err := grpcServer.Serve(listener); err != nil {
+		log.Printf("[!] Failed to serve gRPC : %s\n", err.Error())
+	}
+}
+
+// Replay - Streams replay of given subscription request, until its EOF
+func (s *server) Replay(req *pb.Request, stream grpc.ServerStreamingServer[pb.Frame]) error {
+
+	sess, err := subscribe(s.queue, s.broker, s.resolver, req, stream.Send)
+	if err != nil {
+		return err
+	}
+	defer sess.close()
+
+	for {
+
+		select {
+
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+
+		case <-sess.stream.eof:
+			return nil
+
+		case err, ok := <-sess.failed:
+
+			// Request got released without failing i.e. stopped,
+			// while its EOF is still to be streamed
+			if !ok {
+				sess.failed = nil
+				break
+			}
+
+			log.Printf("[!] Failed to process order %s : %s\n", sess.request.ID, err.Error())
+
+			return status.Error(codes.Internal, err.Error())
+
+		}
+
+	}
+}
+
+// Control - Streams replay of subscription request received first, while applying
+// control requests received afterwards, until its EOF or until it's unsubscribed
+func (s *server) Control(stream grpc.BidiStreamingServer[pb.Request, pb.Frame]) error {
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	if req.Type != "subscribe" {
+		return status.Error(codes.InvalidArgument, "First request is to be subscription request")
+	}
+
+	sess, err := subscribe(s.queue, s.broker, s.resolver, req, stream.Send)
+	if err != nil {
+		return err
+	}
+	defer sess.close()
+
+	// Control requests are read in their own go routine, until client
+	// is done sending them, so that replay can be watched meanwhile
+	requests := make(chan *pb.Request)
+
+	go func() {
+
+		defer close(requests)
+
+		for {
+
+			req, err := stream.Recv()
+			if err != nil {
+				return
+			}
+
+			select {
+			case requests <- req:
+			case <-stream.Context().Done():
+				return
+			}
+
+		}
+
+	}()
+
+	for {
+
+		select {
+
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+
+		case <-sess.stream.eof:
+			return nil
+
+		case err, ok := <-sess.failed:
+
+			// Request got released without failing i.e. stopped,
+			// while its EOF is still to be streamed
+			if !ok {
+				sess.failed = nil
+				break
+			}
+
+			log.Printf("[!] Failed to process order %s : %s\n", sess.request.ID, err.Error())
+
+			return status.Error(codes.Internal, err.Error())
+
+		case _req, ok := <-requests:
+
+			// Client won't be controlling replay anymore, but it's
+			// still to be streamed till the end
+			if !ok {
+				requests = nil
+				break
+			}
+
+			var req ps.SubscriptionRequest
+
+			if err := ps.FromProto(_req, &req); err != nil {
+				if err := sess.respond(&ps.SubscriptionResponse{Code: 0, ID: _req.Id, Message: "Bad Payload"}); err != nil {
+					return err
+				}
+				break
+			}
+
+			// Only replay of this stream can be controlled, which is
+			// assumed when no id is given
+			if req.ID == "" {
+				req.ID = sess.request.ID
+			}
+
+			if req.ID != sess.request.ID {
+				if err := sess.respond(&ps.SubscriptionResponse{Code: 0, ID: req.ID, Message: "Unknown subscription"}); err != nil {
+					return err
+				}
+				break
+			}
+
+			if req.Type == "unsubscribe" {
+				return nil
+			}
+
+			resp := &ps.SubscriptionResponse{Code: 1, ID: req.ID}
+
+			applied, message := s.queue.Control(&req)
+			if !applied {
+				resp.Code = 0
+				message = fmt.Sprintf("Failed to %s `%s`", req.Type, req.ID)
+			}
+			resp.Message = message
+
+			if err := sess.respond(resp); err != nil {
+				return err
+			}
+
+		}
+
+	}
+}
+
+// recoverUnary - Fails unary call with internal error, instead of crashing, if its handler panics
+func recoverUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[!] Recovered from panic in %s : %v\n%s\n", info.FullMethod, r, debug.Stack())
+			err = status.Error(codes.Internal, "Internal error")
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// recoverStream - Fails streaming call with internal error, instead of crashing, if its handler panics
+func recoverStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[!] Recovered from panic in %s : %v\n%s\n", info.FullMethod, r, debug.Stack())
+			err = status.Error(codes.Internal, "Internal error")
+		}
+	}()
+
+	return handler(srv, stream)
+}
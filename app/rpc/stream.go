@@ -0,0 +1,178 @@
+package rpc
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/denniswon/tcex/app/broker"
+	ds "github.com/denniswon/tcex/app/dataset"
+	"github.com/denniswon/tcex/app/pb"
+	ps "github.com/denniswon/tcex/app/pubsub"
+	q "github.com/denniswon/tcex/app/queue"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// frameStream - gRPC stream, which pubsub consumers can deliver replayed data
+// over, same as they do over websocket connection
+//
+// To be written to only while holding connection lock
+type frameStream struct {
+	send   func(*pb.Frame) error
+	eof    chan struct{} // closed once replay EOF is delivered
+	closed bool
+}
+
+// WriteMessage - Sends protobuf encoded frame, as it is, where message type
+// is ignored, given frames are always binary
+func (f *frameStream) WriteMessage(messageType int, data []byte) error {
+
+	var frame pb.Frame
+	if err := proto.Unmarshal(data, &frame); err != nil {
+		return err
+	}
+
+	return f.sendFrame(&frame)
+}
+
+// WriteData - Sends data wrapped in frame, without encoding it first, as
+// gRPC does that on its own
+func (f *frameStream) WriteData(data interface{}) error {
+
+	frame, err := ps.ProtoFrame(data)
+	if err != nil {
+		return err
+	}
+
+	return f.sendFrame(frame)
+}
+
+// sendFrame - Sends frame, unless stream is closed, noting whether replay is over
+func (f *frameStream) sendFrame(frame *pb.Frame) error {
+
+	if f.closed {
+		return errors.New("stream closed")
+	}
+
+	if err := f.send(frame); err != nil {
+		return err
+	}
+
+	// Nothing more to be replayed
+	if frame.GetEof() != nil {
+		select {
+		case <-f.eof:
+		default:
+			close(f.eof)
+		}
+	}
+
+	return nil
+}
+
+// session - Replay of one subscription request, being streamed over gRPC
+type session struct {
+	queue     *q.RequestQueue
+	request   *ps.SubscriptionRequest
+	failed    <-chan error // failure of this request only, closed once it's released
+	stream    *frameStream
+	manager   *ps.SubscriptionManager
+	connLock  *sync.Mutex
+	topicLock *sync.RWMutex
+}
+
+// subscribe - Starts replay of given subscription request, to be streamed using
+// given send function, returning gRPC status error if it can't be started
+func subscribe(_queue *q.RequestQueue, _broker broker.Broker, resolver *ds.Resolver, _req *pb.Request, send func(*pb.Frame) error) (*session, error) {
+
+	var req ps.SubscriptionRequest
+
+	if err := ps.FromProto(_req, &req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Bad Payload")
+	}
+
+	// gRPC streams carry protobuf frames only
+	req.Type = "subscribe"
+	req.Format = "protobuf"
+	req.Generate()
+
+	if !req.Resolve(resolver) {
+		return nil, status.Error(codes.InvalidArgument, "Bad Payload")
+	}
+
+	// Request id is reserved first, so that topic of any other
+	// client's replay is never subscribed to
+	failed, err := _queue.Reserve(&req)
+	if errors.Is(err, q.ErrDuplicate) {
+		return nil, status.Error(codes.AlreadyExists, "Duplicate subscription")
+	}
+
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Bad Payload")
+	}
+
+	connLock := sync.Mutex{}
+	topicLock := sync.RWMutex{}
+
+	s := &session{
+		queue:     _queue,
+		request:   &req,
+		failed:    failed,
+		stream:    &frameStream{send: send, eof: make(chan struct{})},
+		connLock:  &connLock,
+		topicLock: &topicLock,
+	}
+
+	s.manager = &ps.SubscriptionManager{
+		Topics:     make(map[string]*ps.SubscriptionRequest),
+		Consumers:  make(map[string]ps.Consumer),
+		Broker:     _broker,
+		Connection: s.stream,
+		ConnLock:   &connLock,
+		TopicLock:  &topicLock,
+	}
+
+	// Subscribing before request is started, not to miss orders
+	// published right after request is admitted
+	if err := s.manager.Subscribe(&req); err != nil {
+		log.Printf("[!] Failed to subscribe to %s : %s\n", req.ID, err.Error())
+
+		_queue.Remove(req.ID)
+		return nil, status.Error(codes.InvalidArgument, "Bad Payload")
+	}
+
+	if !_queue.Put(req.ID) {
+		s.close()
+		return nil, status.Error(codes.InvalidArgument, "Bad Payload")
+	}
+
+	return s, nil
+}
+
+// respond - Sends response to control request over stream
+func (s *session) respond(resp *ps.SubscriptionResponse) error {
+
+	s.connLock.Lock()
+	defer s.connLock.Unlock()
+
+	return s.stream.WriteData(resp)
+}
+
+// close - Cancels replay & unsubscribes, after which nothing can be sent
+// over stream anymore, given it's done with once rpc handler returns
+func (s *session) close() {
+
+	s.topicLock.Lock()
+	for k, v := range s.manager.Consumers {
+		s.queue.Remove(k)
+		v.Unsubscribe()
+	}
+	s.topicLock.Unlock()
+
+	s.connLock.Lock()
+	s.stream.closed = true
+	s.connLock.Unlock()
+
+}
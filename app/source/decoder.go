@@ -0,0 +1,333 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	d "github.com/denniswon/tcex/app/data"
+)
+
+// Supported layouts of trade files
+const (
+	JSONL = "jsonl" // one JSON encoded trade per line
+	CSV   = "csv"   // comma separated values, with header line
+	TSV   = "tsv"   // tab separated values, with header line
+)
+
+// Fields - Trade fields, each of which needs to be found in header of csv & tsv files
+var Fields = []string{"price", "quantity", "aggressor", "timestamp"}
+
+// maxHeader - Max length of header line of csv & tsv files
+const maxHeader = 64 << 10
+
+// Options - How trade file is to be decoded, where anything left empty
+// is either detected or defaulted
+type Options struct {
+	Format  string            // "jsonl", "csv" or "tsv", detected from file extension or content if empty
+	Unit    string            // unit of trade timestamps i.e. "s", "ms" ( default ), "us" or "ns"
+	Columns map[string]string // header name of trade fields, in csv & tsv files, defaulting to field name
+}
+
+// Check - Checks whether options are supported
+func (o *Options) Check() error {
+
+	switch o.Format {
+	case "", JSONL, CSV, TSV:
+	default:
+		return fmt.Errorf("unknown file format `%s`", o.Format)
+	}
+
+	if _, err := unitScale(o.Unit); err != nil {
+		return err
+	}
+
+	for field, column := range o.Columns {
+
+		known := false
+		for _, _field := range Fields {
+			known = known || field == _field
+		}
+
+		if !known {
+			return fmt.Errorf("unknown trade field `%s`", field)
+		}
+
+		if column == "" {
+			return fmt.Errorf("empty column name for `%s`", field)
+		}
+
+	}
+
+	return nil
+}
+
+// Decoder - Decodes lines of trade file into orders, with timestamps in milliseconds
+type Decoder struct {
+	Format  string
+	Unit    string
+	Columns map[string]string
+	Start   int64 // offset of the first line, which can hold a trade i.e. past header line
+
+	scale   float64 // milliseconds per timestamp unit
+	comma   rune
+	indexes [4]int // column index of each trade field, in the same order as `Fields`
+	width   int    // # of columns in header
+}
+
+// NewDecoder - Creates decoder for given trade file, where format is detected from
+// file name or its first line, unless it's given. Header of csv & tsv files is read
+// for finding column of each trade field
+func NewDecoder(file *File, name string, options Options) (*Decoder, error) {
+
+	if err := options.Check(); err != nil {
+		return nil, err
+	}
+
+	header, err := firstLine(file)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := &Decoder{
+		Format: options.Format,
+		Unit:   options.Unit,
+	}
+
+	if decoder.Format == "" {
+		decoder.Format = Detect(name, header)
+	}
+
+	if decoder.Unit == "" {
+		decoder.Unit = "ms"
+	}
+
+	decoder.scale, _ = unitScale(decoder.Unit)
+
+	if decoder.Format == JSONL {
+		return decoder, nil
+	}
+
+	// Header line is never replayed
+	decoder.Start = int64(len(header))
+
+	decoder.comma = ','
+	if decoder.Format == TSV {
+		decoder.comma = '\t'
+	}
+
+	columns, err := decoder.split(header)
+	if err != nil {
+		return nil, fmt.Errorf("bad header : %s", err.Error())
+	}
+
+	decoder.width = len(columns)
+	decoder.Columns = make(map[string]string, len(Fields))
+
+	for i, field := range Fields {
+
+		column := field
+		if _column, ok := options.Columns[field]; ok {
+			column = _column
+		}
+
+		decoder.indexes[i] = -1
+		for j, _column := range columns {
+			if strings.EqualFold(strings.TrimSpace(_column), column) {
+				decoder.indexes[i] = j
+				break
+			}
+		}
+
+		if decoder.indexes[i] < 0 {
+			return nil, fmt.Errorf("missing column `%s` for trade %s", column, field)
+		}
+
+		decoder.Columns[field] = column
+
+	}
+
+	return decoder, nil
+}
+
+// Detect - Tells format of trade file from its extension, or from its
+// first line, if extension is not known
+func Detect(name string, line []byte) string {
+
+	// Compressed files are named after decompressed ones i.e. `trades.csv.gz`
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz", ".gzip", ".zst", ".zstd":
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsonl", ".ndjson", ".json":
+		return JSONL
+	case ".csv":
+		return CSV
+	case ".tsv", ".tab":
+		return TSV
+	}
+
+	line = bytes.TrimSpace(line)
+
+	switch {
+	case bytes.HasPrefix(line, []byte("{")):
+		return JSONL
+	case bytes.ContainsRune(line, '\t'):
+		return TSV
+	}
+
+	return CSV
+}
+
+// Decode - Decodes one line of trade file, which can't be header line
+func (dec *Decoder) Decode(line []byte) (d.Order, error) {
+
+	line = bytes.TrimRight(line, "\r\n")
+
+	if dec.Format == JSONL {
+		return dec.decodeJSON(line)
+	}
+
+	var order d.Order
+
+	values, err := dec.split(line)
+	if err != nil {
+		return order, err
+	}
+
+	if len(values) != dec.width {
+		return order, fmt.Errorf("expected %d columns, found %d", dec.width, len(values))
+	}
+
+	order.Price = strings.TrimSpace(values[dec.indexes[0]])
+
+	quantity, err := strconv.ParseUint(strings.TrimSpace(values[dec.indexes[1]]), 10, 64)
+	if err != nil {
+		return order, fmt.Errorf("bad quantity `%s`", values[dec.indexes[1]])
+	}
+	order.Quantity = quantity
+
+	order.Aggressor = strings.TrimSpace(values[dec.indexes[2]])
+
+	timestamp, err := dec.millis(strings.TrimSpace(values[dec.indexes[3]]))
+	if err != nil {
+		return order, err
+	}
+	order.Timestamp = timestamp
+
+	return order, nil
+}
+
+// decodeJSON - Decodes JSON encoded trade, with timestamp in any unit
+func (dec *Decoder) decodeJSON(line []byte) (d.Order, error) {
+
+	var order d.Order
+
+	var trade struct {
+		Price     string      `json:"price"`
+		Quantity  uint64      `json:"quantity"`
+		Aggressor string      `json:"aggressor"`
+		Timestamp json.Number `json:"timestamp"`
+	}
+
+	if err := json.Unmarshal(line, &trade); err != nil {
+		return order, err
+	}
+
+	timestamp, err := dec.millis(trade.Timestamp.String())
+	if err != nil {
+		return order, err
+	}
+
+	order.Price = trade.Price
+	order.Quantity = trade.Quantity
+	order.Aggressor = trade.Aggressor
+	order.Timestamp = timestamp
+
+	return order, nil
+}
+
+// millis - Converts timestamp in decoder's unit to milliseconds
+func (dec *Decoder) millis(value string) (int64, error) {
+
+	if timestamp, err := strconv.ParseInt(value, 10, 64); err == nil {
+		switch dec.Unit {
+		case "s":
+			return timestamp * 1000, nil
+		case "us":
+			return timestamp / 1000, nil
+		case "ns":
+			return timestamp / 1000000, nil
+		}
+
+		return timestamp, nil
+	}
+
+	timestamp, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(timestamp) || math.IsInf(timestamp, 0) {
+		return 0, fmt.Errorf("bad timestamp `%s`", value)
+	}
+
+	// Rounded to microseconds first, so that float error of fractional
+	// seconds i.e. 1.63 * 1000 = 1629.9999.. is not truncated away
+	return int64(math.Floor(math.Round(timestamp*dec.scale*1000) / 1000)), nil
+}
+
+// split - Splits line of csv & tsv file into its values
+func (dec *Decoder) split(line []byte) ([]string, error) {
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimRight(line, "\r\n")))
+	reader.Comma = dec.comma
+	reader.LazyQuotes = dec.comma == '\t'
+	reader.FieldsPerRecord = -1
+
+	return reader.Read()
+}
+
+// unitScale - Milliseconds per given timestamp unit
+func unitScale(unit string) (float64, error) {
+
+	switch unit {
+	case "s":
+		return 1000, nil
+	case "", "ms":
+		return 1, nil
+	case "us":
+		return 0.001, nil
+	case "ns":
+		return 0.000001, nil
+	}
+
+	return 0, fmt.Errorf("unknown timestamp unit `%s`", unit)
+}
+
+// firstLine - Reads first line of trade file, including its line break
+func firstLine(file *File) ([]byte, error) {
+
+	section, err := file.Section(0)
+	if err != nil {
+		return nil, err
+	}
+	defer section.Close()
+
+	line, err := bufio.NewReaderSize(section, maxHeader).ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return nil, errors.New("first line too long")
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return bytes.Clone(line), nil
+}
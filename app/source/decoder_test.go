@@ -0,0 +1,274 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	d "github.com/denniswon/tcex/app/data"
+)
+
+// decoderFor - Creates decoder of trade file with given name & content
+func decoderFor(t *testing.T, name string, content string, options Options) (*Decoder, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	return NewDecoder(file, name, options)
+}
+
+func TestDecoderColumns(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		options  Options
+		line     string
+		expected d.Order
+		err      string
+	}{
+		{
+			name:     "default columns",
+			file:     "trades.csv",
+			content:  "price,quantity,aggressor,timestamp\n",
+			line:     "1.5,10,buy,1630000000000",
+			expected: d.Order{Price: "1.5", Quantity: 10, Aggressor: "buy", Timestamp: 1630000000000},
+		},
+		{
+			name:     "reordered with extra column",
+			file:     "trades.csv",
+			content:  "id,timestamp,aggressor,quantity,price\n",
+			line:     "7,1630000000000,sell,3,2.25",
+			expected: d.Order{Price: "2.25", Quantity: 3, Aggressor: "sell", Timestamp: 1630000000000},
+		},
+		{
+			name:     "header case & spaces ignored",
+			file:     "trades.csv",
+			content:  " Price ,QUANTITY,Aggressor,TimeStamp\r\n",
+			line:     "1.5, 10 ,buy,1630000000000\r\n",
+			expected: d.Order{Price: "1.5", Quantity: 10, Aggressor: "buy", Timestamp: 1630000000000},
+		},
+		{
+			name:     "mapped columns",
+			file:     "trades.csv",
+			content:  "px,qty,side,ts\n",
+			options:  Options{Columns: map[string]string{"price": "px", "quantity": "qty", "aggressor": "side", "timestamp": "ts"}},
+			line:     "1.5,10,buy,1630000000000",
+			expected: d.Order{Price: "1.5", Quantity: 10, Aggressor: "buy", Timestamp: 1630000000000},
+		},
+		{
+			name:     "tab separated",
+			file:     "trades.tsv",
+			content:  "price\tquantity\taggressor\ttimestamp\n",
+			line:     "1.5\t10\tbuy\t1630000000000",
+			expected: d.Order{Price: "1.5", Quantity: 10, Aggressor: "buy", Timestamp: 1630000000000},
+		},
+		{
+			name:     "tab separated detected from content",
+			file:     "trades",
+			content:  "price\tquantity\taggressor\ttimestamp\n",
+			line:     "1.5\t10\tbuy\t1630000000000",
+			expected: d.Order{Price: "1.5", Quantity: 10, Aggressor: "buy", Timestamp: 1630000000000},
+		},
+		{
+			name:     "json lines",
+			file:     "trades.jsonl",
+			content:  `{"price":"1.5","quantity":10,"aggressor":"buy","timestamp":1630000000000}` + "\n",
+			line:     `{"price":"1.5","quantity":10,"aggressor":"buy","timestamp":1630000000000}`,
+			expected: d.Order{Price: "1.5", Quantity: 10, Aggressor: "buy", Timestamp: 1630000000000},
+		},
+		{
+			name:    "missing column",
+			file:    "trades.csv",
+			content: "price,quantity,timestamp\n",
+			err:     "missing column `aggressor` for trade aggressor",
+		},
+		{
+			name:    "missing mapped column",
+			file:    "trades.csv",
+			content: "price,quantity,aggressor,timestamp\n",
+			options: Options{Columns: map[string]string{"timestamp": "ts"}},
+			err:     "missing column `ts` for trade timestamp",
+		},
+		{
+			name:    "unknown field",
+			file:    "trades.csv",
+			content: "price,quantity,aggressor,timestamp\n",
+			options: Options{Columns: map[string]string{"side": "aggressor"}},
+			err:     "unknown trade field `side`",
+		},
+		{
+			name:    "row too short",
+			file:    "trades.csv",
+			content: "price,quantity,aggressor,timestamp\n",
+			line:    "1.5,10,buy",
+			err:     "expected 4 columns, found 3",
+		},
+		{
+			name:    "bad quantity",
+			file:    "trades.csv",
+			content: "price,quantity,aggressor,timestamp\n",
+			line:    "1.5,ten,buy,1630000000000",
+			err:     "bad quantity `ten`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			decoder, err := decoderFor(t, tt.file, tt.content, tt.options)
+			if err == nil && tt.line != "" {
+				var order d.Order
+				order, err = decoder.Decode([]byte(tt.line))
+
+				if err == nil && order != tt.expected {
+					t.Fatalf("Decode(%q) : expected %+v, got %+v", tt.line, tt.expected, order)
+				}
+			}
+
+			if tt.err == "" && err != nil {
+				t.Fatalf("unexpected error : %s", err)
+			}
+
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("expected error %q, got %v", tt.err, err)
+			}
+
+		})
+	}
+
+}
+
+func TestDecoderStart(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		format  string
+		start   int64
+	}{
+		{"csv header skipped", "trades.csv", "price,quantity,aggressor,timestamp\n1.5,10,buy,1\n", CSV, 35},
+		{"crlf header skipped", "trades.csv", "price,quantity,aggressor,timestamp\r\n1.5,10,buy,1\r\n", CSV, 36},
+		{"compressed name", "trades.csv.gz", "price,quantity,aggressor,timestamp\n", CSV, 35},
+		{"jsonl has no header", "trades.txt", `{"price":"1.5","quantity":10,"aggressor":"buy","timestamp":1}` + "\n", JSONL, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			decoder, err := decoderFor(t, tt.file, tt.content, Options{})
+			if err != nil {
+				t.Fatalf("unexpected error : %s", err)
+			}
+
+			if decoder.Format != tt.format || decoder.Start != tt.start {
+				t.Fatalf("expected format %s starting at %d, got %s starting at %d",
+					tt.format, tt.start, decoder.Format, decoder.Start)
+			}
+
+		})
+	}
+
+}
+
+func TestDecoderTimestampUnits(t *testing.T) {
+
+	tests := []struct {
+		unit      string
+		timestamp string
+		expected  int64
+		err       bool
+	}{
+		{"", "1630000000123", 1630000000123, false},
+		{"ms", "1630000000123", 1630000000123, false},
+		{"s", "1630000000", 1630000000000, false},
+		{"s", "1.63", 1630, false},
+		{"s", "1630000000.1239", 1630000000123, false},
+		{"us", "1630000000123456", 1630000000123, false},
+		{"ns", "1630000000123456789", 1630000000123, false},
+		{"ms", "1630000000123.9", 1630000000123, false},
+		{"ms", "-1", -1, false},
+		{"ms", "soon", 0, true},
+		{"ms", "NaN", 0, true},
+		{"ms", "Inf", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit+"/"+tt.timestamp, func(t *testing.T) {
+
+			for _, file := range []string{"trades.csv", "trades.jsonl"} {
+
+				content := "price,quantity,aggressor,timestamp\n"
+				line := "1.5,10,buy," + tt.timestamp
+				if file == "trades.jsonl" {
+					line = `{"price":"1.5","quantity":10,"aggressor":"buy","timestamp":` + tt.timestamp + `}`
+					content = line + "\n"
+				}
+
+				decoder, err := decoderFor(t, file, content, Options{Unit: tt.unit})
+				if err != nil {
+					t.Fatalf("%s : unexpected error : %s", file, err)
+				}
+
+				order, err := decoder.Decode([]byte(line))
+
+				// Only numbers are valid JSON timestamps, so bad ones fail either way
+				if tt.err {
+					if err == nil {
+						t.Fatalf("%s : expected error, got %d", file, order.Timestamp)
+					}
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("%s : unexpected error : %s", file, err)
+				}
+
+				if order.Timestamp != tt.expected {
+					t.Fatalf("%s : expected %d, got %d", file, tt.expected, order.Timestamp)
+				}
+
+			}
+
+		})
+	}
+
+}
+
+func TestOptionsCheck(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		options Options
+		ok      bool
+	}{
+		{"defaults", Options{}, true},
+		{"all set", Options{Format: TSV, Unit: "ns", Columns: map[string]string{"price": "px"}}, true},
+		{"unknown format", Options{Format: "xml"}, false},
+		{"unknown unit", Options{Unit: "min"}, false},
+		{"empty column", Options{Columns: map[string]string{"price": ""}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if err := tt.options.Check(); (err == nil) != tt.ok {
+				t.Fatalf("Check() : expected ok %t, got %v", tt.ok, err)
+			}
+
+		})
+	}
+
+}
@@ -0,0 +1,144 @@
+package source
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported compressions of trade files
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+// magic - Leading bytes of compressed files
+var magic = map[string][]byte{
+	Gzip: {0x1f, 0x8b},
+	Zstd: {0x28, 0xb5, 0x2f, 0xfd},
+}
+
+// File - Trade file, which is decompressed as it's read, if it's compressed. Offsets
+// are always the ones in decompressed file, while compressed files can only be read
+// from their start, so that they're never seeked
+type File struct {
+	*os.File
+	Compression string // "gzip" or "zstd", empty if file is not compressed
+}
+
+// Open - Opens trade file, telling whether it's compressed from its leading bytes
+func Open(name string) (*File, error) {
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, 4)
+
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+
+	_file := &File{File: file}
+
+	for compression, prefix := range magic {
+		if bytes.HasPrefix(head[:n], prefix) {
+			_file.Compression = compression
+		}
+	}
+
+	return _file, nil
+}
+
+// Compressed - Whether file is read by decompressing it
+func (f *File) Compressed() bool {
+	return f.Compression != ""
+}
+
+// Section - Reads decompressed file from given offset till its end, without moving
+// offset of the file itself, so that same file can be read for multiple replays at once
+//
+// Compressed file is decompressed from its start, skipping everything before offset
+func (f *File) Section(offset int64) (io.ReadCloser, error) {
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if !f.Compressed() {
+		return io.NopCloser(io.NewSectionReader(f.File, offset, info.Size()-offset)), nil
+	}
+
+	reader, err := NewReader(io.NewSectionReader(f.File, 0, info.Size()), f.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.CopyN(io.Discard, reader, offset); err != nil && err != io.EOF {
+		reader.Close()
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// NewReader - Decompresses given reader, as per given compression
+func NewReader(r io.Reader, compression string) (io.ReadCloser, error) {
+
+	switch compression {
+
+	case "":
+		return io.NopCloser(r), nil
+
+	case Gzip:
+		return gzip.NewReader(r)
+
+	case Zstd:
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+
+		return decoder.IOReadCloser(), nil
+
+	}
+
+	return nil, fmt.Errorf("unknown compression `%s`", compression)
+}
+
+// NewWriter - Compresses whatever is written to given writer, as per given compression,
+// where closing returned writer flushes it, without closing the given one
+func NewWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+
+	switch compression {
+
+	case "":
+		return nopWriteCloser{w}, nil
+
+	case Gzip:
+		return gzip.NewWriter(w), nil
+
+	case Zstd:
+		return zstd.NewWriter(w)
+
+	}
+
+	return nil, fmt.Errorf("unknown compression `%s`", compression)
+}
+
+// nopWriteCloser - Writer, which has nothing to flush when closed
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,138 @@
+package source
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile - Writes given content into file, compressed as given
+func writeFile(t *testing.T, name string, content string, compression string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer, err := NewWriter(file, compression)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := io.WriteString(writer, content); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestFileSection(t *testing.T) {
+
+	content := "price,quantity,aggressor,timestamp\n1.5,10,bid,1000\n2.5,5,ask,2000\n"
+
+	tests := []struct {
+		name        string
+		file        string
+		compression string
+	}{
+		{"plain", "trades.csv", ""},
+		{"gzip", "trades.csv.gz", Gzip},
+		{"zstd", "trades.csv.zst", Zstd},
+		// Compression is told from content, not from name
+		{"misnamed gzip", "trades.csv", Gzip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			file, err := Open(writeFile(t, tt.file, content, tt.compression))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			if file.Compression != tt.compression || file.Compressed() != (tt.compression != "") {
+				t.Fatalf("expected compression `%s`, got `%s`", tt.compression, file.Compression)
+			}
+
+			// Offsets are the ones in decompressed file
+			for _, offset := range []int64{0, 35, int64(len(content))} {
+
+				reader, err := file.Section(offset)
+				if err != nil {
+					t.Fatalf("Section(%d) : %s", offset, err)
+				}
+
+				got, err := io.ReadAll(reader)
+				reader.Close()
+
+				if err != nil || string(got) != content[offset:] {
+					t.Fatalf("Section(%d) : expected %q, got %q %v", offset, content[offset:], got, err)
+				}
+
+			}
+
+			// Sections are read independently of each other
+			first, _ := file.Section(35)
+			second, _ := file.Section(0)
+			defer first.Close()
+			defer second.Close()
+
+			head := make([]byte, 5)
+			if _, err := io.ReadFull(first, head); err != nil || string(head) != "1.5,1" {
+				t.Fatalf("expected first section to start at trade, got %q %v", head, err)
+			}
+
+			if _, err := io.ReadFull(second, head); err != nil || string(head) != "price" {
+				t.Fatalf("expected second section to start at header, got %q %v", head, err)
+			}
+
+		})
+	}
+
+}
+
+func TestDecoderCompressed(t *testing.T) {
+
+	for _, compression := range []string{Gzip, Zstd} {
+		t.Run(compression, func(t *testing.T) {
+
+			file, err := Open(writeFile(t, "trades.tsv."+compression, "timestamp\tprice\tquantity\taggressor\n1000\t1.5\t10\tbid\n", compression))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			// Format is detected from name, without compression extension,
+			// while header is read from decompressed file
+			decoder, err := NewDecoder(file, "trades.tsv.gz", Options{})
+			if err != nil {
+				t.Fatalf("NewDecoder : %s", err)
+			}
+
+			if decoder.Format != TSV || decoder.Start != 35 {
+				t.Fatalf("expected tsv starting at 35, got %s starting at %d", decoder.Format, decoder.Start)
+			}
+
+			order, err := decoder.Decode([]byte("1000\t1.5\t10\tbid"))
+			if err != nil || order.Price != "1.5" || order.Timestamp != 1000 {
+				t.Fatalf("Decode : got %+v %v", order, err)
+			}
+
+		})
+	}
+
+	if _, err := NewReader(nil, "lz4"); err == nil {
+		t.Fatal("NewReader : expected error for unknown compression")
+	}
+
+}